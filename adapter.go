@@ -0,0 +1,79 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// contextKey is the request context key holding the active *Context while
+// a net/http middleware is running.
+type contextKey struct{}
+
+// WrapMiddleware converts a net/http style middleware, as used by chi and
+// gorilla, into a MiddlewareFunc so existing middleware can be reused as is.
+//
+// The wrapped middleware is constructed once per route, not per request.
+// Caveats:
+//   - If the middleware replaces the ResponseWriter or Request before calling
+//     next, the replacements are used by the rest of the chain. Once the
+//     middleware returns, the original ones are restored for the outer
+//     middleware and the ErrorHandler.
+//   - If the middleware writes a response without calling next, the chain
+//     stops there and no error is returned.
+//   - Errors returned further down the chain are answered by the
+//     ErrorHandler, as with Context.HandleError, before next returns, so
+//     the error response passes through the middleware, e.g. to be
+//     compressed. The error is then handed back to mux; the wrapped
+//     middleware never sees it.
+func WrapMiddleware(m func(http.Handler) http.Handler) MiddlewareFunc {
+	return func(next Handler) Handler {
+		h := m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(contextKey{}).(*Context)
+			ctx.req = r
+			ctx.res = w
+			err := next.Handle(ctx)
+			// Respond while the middleware can still process the response.
+			ctx.HandleError(err)
+			ctx.adapterErr = err
+		}))
+
+		return HandlerFunc(func(ctx *Context) error {
+			req, res := ctx.req, ctx.res
+			h.ServeHTTP(res, req.WithContext(context.WithValue(req.Context(), contextKey{}, ctx)))
+			ctx.req, ctx.res = req, res
+
+			// Hand back the error captured by the inner handler, if any.
+			err := ctx.adapterErr
+			ctx.adapterErr = nil
+			return err
+		})
+	}
+}
+
+// WrapHandler converts a net/http handler into a Handler. h writes to the
+// response of the Context and sees the request as modified by the chain;
+// it never returns an error.
+func WrapHandler(h http.Handler) Handler {
+	return HandlerFunc(func(ctx *Context) error {
+		h.ServeHTTP(ctx.res, ctx.req)
+		return nil
+	})
+}
+
+// WrapHandlerFunc converts a net/http handler function into a Handler.
+func WrapHandlerFunc(f func(http.ResponseWriter, *http.Request)) Handler {
+	return WrapHandler(http.HandlerFunc(f))
+}
+
+// standalone is the App running handlers converted by ToHTTPHandler.
+var standalone = sync.OnceValue(func() *App { return New(Config{}) })
+
+// ToHTTPHandler converts a Handler into a net/http handler, so it can be
+// served by http.ServeMux or wrapped by net/http middleware. h runs with a
+// default Config: errors are answered by DefaultErrorHandler. To serve
+// with an application's settings, mount the App itself or use Group.Handler.
+func ToHTTPHandler(h Handler) http.Handler {
+	route := &Route{app: standalone(), handlerName: handlerName(h), handler: h}
+	return http.HandlerFunc(route.serve)
+}
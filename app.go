@@ -1,8 +1,11 @@
 package mux
 
 import (
+	"fmt"
 	"net/http"
+	"net/netip"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,11 +24,76 @@ type App struct {
 	// server is the underlying HTTP server.
 	server *http.Server
 
-	// mux is the HTTP request multiplexer for routing
-	mux *http.ServeMux
+	// router matches requests to routes.
+	router router
 
-	// middleware holds the global middleware stack
-	middleware []MiddlewareFunc
+	// hosts holds the routers of the host patterns registered with Host,
+	// replaced as a whole when a host is added.
+	hosts atomic.Pointer[[]*hostRouter]
+
+	// middleware holds the global middleware stack.
+	// It is replaced as a whole on Use so the request path can read it without locking.
+	middleware atomic.Pointer[[]namedMiddleware]
+
+	// requests tracks request counters and latencies.
+	requests requestTracker
+
+	// binds tracks request body decoding per content type.
+	binds bindTracker
+
+	// cache is the cache returned by Cache.
+	cache *Cache
+
+	// conns tracks connection states reported by the server.
+	conns connTracker
+
+	// streams tracks long-lived connections for graceful shutdown.
+	streams streamTracker
+
+	// drainEnd is the expected end of the shutdown in Unix nanoseconds,
+	// or 0 while the app is not shutting down.
+	drainEnd atomic.Int64
+
+	// unavailable answers requests while the app is shutting down.
+	unavailable *Route
+
+	// notFound, methodNotAllowed and options run the handlers for
+	// unmatched requests.
+	notFound         *Route
+	methodNotAllowed *Route
+	options          *Route
+
+	// trustedProxies holds the parsed Config.TrustedProxies.
+	trustedProxies []netip.Prefix
+
+	// errorGroups lists the groups with an error handler, for unmatched requests.
+	errorGroups []*Group
+
+	// routes is the registry of every registered route, in registration order.
+	routes []*Route
+
+	// names maps route names to their routes for reverse routing.
+	names map[string]*Route
+
+	// namedMiddleware holds the middleware registered by name.
+	namedMiddleware map[string]MiddlewareFunc
+
+	// handlers holds the named handler factories used by LoadRoutes.
+	handlers map[string]HandlerFactory
+
+	// codecs holds the codecs registered by media type.
+	// It is replaced as a whole on RegisterCodec.
+	codecs atomic.Pointer[map[string]Codec]
+
+	// plugins holds the installed plugins by name, and a nil entry for
+	// those being installed.
+	plugins map[string]Plugin
+
+	// mounted lists the apps attached with Mount.
+	mounted []*App
+
+	// hooks holds the lifecycle hooks returned by Hooks.
+	hooks Hooks
 }
 
 // Config is a struct holding the server settings.
@@ -36,6 +104,44 @@ type Config struct {
 	// Default: 4 * 1024 * 1024
 	BodyLimit int `json:"body_limit"`
 
+	// MultipartMemory is the memory used to buffer the parts of a
+	// multipart form before they spill to temporary files. The body as a
+	// whole is still bounded by BodyLimit.
+	//
+	// Default: 32 * 1024 * 1024
+	MultipartMemory int64 `json:"multipart_memory"`
+
+	// MaxParseDuration bounds the time Bind and the Bind* methods spend
+	// reading a request body, as measured by Clock: reads of the body
+	// starting past it fail with ErrParseTimeout and a 400. It is checked
+	// between reads, so it neither interrupts a blocked read, which
+	// ReadTimeout bounds, nor the decoding of data already read; bound that
+	// with BodyLimit and JSONDecoding.MaxDepth. Zero means no limit.
+	//
+	// Default: 0
+	MaxParseDuration time.Duration `json:"max_parse_duration"`
+
+	// JSONDecoding configures the decoding of JSON request bodies by Bind
+	// and BindJSON. Routes override it with Route.JSONOptions.
+	//
+	// Default: JSONOptions{}
+	JSONDecoding JSONOptions `json:"json_decoding"`
+
+	// DefaultCharset is the charset announced for text responses and used
+	// by SendString unless the client asks for another supported charset
+	// in Accept-Charset: "utf-8", "iso-8859-1" or "us-ascii". New panics
+	// on other values.
+	//
+	// Default: "utf-8"
+	DefaultCharset string `json:"default_charset"`
+
+	// TrustedProxies lists the reverse proxies, as CIDR ranges or single
+	// addresses, whose X-Forwarded-For and X-Real-IP headers Context.IP
+	// and Context.IPs honor. New panics on invalid entries.
+	//
+	// Default: nil
+	TrustedProxies []string `json:"trusted_proxies"`
+
 	// ReadTimeout is the maximum duration for reading the entire request, including the body.
 	// A zero value means no timeout is set by the server.
 	//
@@ -54,10 +160,134 @@ type Config struct {
 	// Default: 60s
 	IdleTimeout time.Duration `json:"idle_timeout"`
 
+	// MaxConnsPerIP limits the number of open connections per remote IP.
+	// Excess connections are closed right after they are accepted.
+	// Zero means no limit.
+	//
+	// Default: 0
+	MaxConnsPerIP int `json:"max_conns_per_ip"`
+
+	// ContextKeyNamespace is prepended to the keys used by Context.Set and
+	// Context.Get when mirroring values into the request's context.Context.
+	//
+	// Default: ""
+	ContextKeyNamespace string `json:"context_key_namespace"`
+
+	// BaggageAllowlist lists the W3C Baggage keys accepted from incoming requests.
+	// Members with other keys are dropped by Context.Baggage.
+	// An empty list accepts every key.
+	//
+	// Default: nil
+	BaggageAllowlist []string `json:"baggage_allowlist"`
+
+	// CookieSameSite is the SameSite attribute of cookies set without one:
+	// "Lax", "Strict" or "None".
+	//
+	// Default: "Lax"
+	CookieSameSite string `json:"cookie_same_site"`
+
+	// CookieSecure marks every cookie set through Context.SetCookie as Secure.
+	//
+	// Default: false
+	CookieSecure bool `json:"cookie_secure"`
+
+	// CookieHTTPOnly marks every cookie set through Context.SetCookie as HttpOnly.
+	//
+	// Default: false
+	CookieHTTPOnly bool `json:"cookie_http_only"`
+
+	// CookieMaxAge is the Max-Age in seconds of cookies set without one.
+	// Zero makes them session cookies.
+	//
+	// Default: 0
+	CookieMaxAge int `json:"cookie_max_age"`
+
+	// ResponseEnvelope wraps JSON responses written by Context.JSON in an
+	// Envelope ({data, error, meta}), and makes DefaultErrorHandler answer
+	// with an Envelope carrying the error.
+	//
+	// Default: false
+	ResponseEnvelope bool `json:"response_envelope"`
+
+	// Development enables development features such as route stubs.
+	//
+	// Default: false
+	Development bool `json:"development"`
+
+	// StubHeader names a request header that activates route stubs when
+	// present, outside of Development mode. Empty disables it.
+	//
+	// Default: ""
+	StubHeader string `json:"stub_header"`
+
+	// ChainHeader names a response header listing each middleware as it
+	// runs, to debug middleware chains. Empty disables the tracing, which
+	// should stay off in production.
+	//
+	// Default: ""
+	ChainHeader string `json:"chain_header"`
+
+	// SSEHeartbeat is the interval of the keep-alive comments sent on
+	// Server-Sent Events streams. A negative value disables them.
+	//
+	// Default: 15s
+	SSEHeartbeat time.Duration `json:"sse_heartbeat"`
+
+	// DrainWindow is the time the server keeps accepting requests once
+	// shutdown begins, answering them with 503 and a Retry-After header so
+	// load balancers and clients move on instead of hitting connection
+	// resets. Requests arriving on open connections while active ones
+	// finish are answered the same way.
+	//
+	// Default: 0
+	DrainWindow time.Duration `json:"drain_window"`
+
+	// StreamGracePeriod is the time long-lived streams, such as WebSocket
+	// and SSE connections, get to close after being notified of a shutdown.
+	// Streams still open afterwards are severed.
+	//
+	// Default: 5s
+	StreamGracePeriod time.Duration `json:"stream_grace_period"`
+
+	// Clock is the time source of time-based features such as login
+	// throttling and timeouts. Handlers reach it with Context.Clock or
+	// ClockFrom(r.Context()).
+	//
+	// Default: SystemClock
+	Clock Clock `json:"-"`
+
+	// CBORCodec encodes and decodes CBOR bodies. When set, it is registered
+	// for application/cbor so Bind and Respond handle CBOR like any other codec.
+	// Any CBOR library can be plugged in through a small Codec adapter.
+	//
+	// Default: nil
+	CBORCodec Codec `json:"-"`
+
 	// ErrorHandler is executed when an error is returned from fiber.Handler.
 	//
 	// Default: DefaultErrorHandler
 	ErrorHandler ErrorHandler `json:"-"`
+
+	// NotFoundHandler handles requests matching no route. It runs through the
+	// global middleware and the ErrorHandler like any route handler.
+	//
+	// Default: a handler returning a 404 *Error
+	NotFoundHandler Handler `json:"-"`
+
+	// MethodNotAllowedHandler handles requests whose path matches a route
+	// registered for other methods only. The Allow header is already set
+	// when it runs.
+	//
+	// Default: a handler returning a 405 *Error
+	MethodNotAllowedHandler Handler `json:"-"`
+
+	// OptionsHandler answers OPTIONS requests whose path matches routes
+	// registered for other methods. The Allow header, listing the methods
+	// of those routes, is already set when it runs. Register an OPTIONS
+	// route to answer a path differently.
+	//
+	// Default: a handler answering 204 No Content
+	OptionsHandler Handler `json:"-"`
 }
 
 // New creates a new Mux application with the given configuration.
@@ -67,6 +297,9 @@ func New(config Config) *App {
 	if config.BodyLimit == 0 {
 		config.BodyLimit = 4 * 1024 * 1024
 	}
+	if config.MultipartMemory <= 0 {
+		config.MultipartMemory = 32 * 1024 * 1024
+	}
 	// Apply default timeouts if unset.
 	if config.ReadTimeout == 0 {
 		config.ReadTimeout = 15 * time.Second
@@ -77,13 +310,55 @@ func New(config Config) *App {
 	if config.IdleTimeout == 0 {
 		config.IdleTimeout = 60 * time.Second
 	}
+	if config.SSEHeartbeat == 0 {
+		config.SSEHeartbeat = 15 * time.Second
+	}
+	if config.StreamGracePeriod == 0 {
+		config.StreamGracePeriod = 5 * time.Second
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock
+	}
+	if config.DefaultCharset == "" {
+		config.DefaultCharset = CharsetUTF8
+	}
+	charset, ok := canonicalCharset(config.DefaultCharset)
+	if !ok {
+		panic(fmt.Sprintf("mux: unsupported DefaultCharset %q", config.DefaultCharset))
+	}
+	config.DefaultCharset = charset
+	trustedProxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		panic(err.Error())
+	}
+	if config.CookieSameSite == "" {
+		config.CookieSameSite = "Lax"
+	}
 	// Assign default error handler if none provided.
 	if config.ErrorHandler == nil {
 		config.ErrorHandler = DefaultErrorHandler
 	}
+	// Assign default handlers for unmatched requests.
+	if config.NotFoundHandler == nil {
+		config.NotFoundHandler = HandlerFunc(func(*Context) error {
+			return NewError(http.StatusNotFound)
+		})
+	}
+	if config.MethodNotAllowedHandler == nil {
+		config.MethodNotAllowedHandler = HandlerFunc(func(*Context) error {
+			return NewError(http.StatusMethodNotAllowed)
+		})
+	}
+	if config.OptionsHandler == nil {
+		config.OptionsHandler = HandlerFunc(func(c *Context) error {
+			c.res.WriteHeader(http.StatusNoContent)
+			return nil
+		})
+	}
 
 	app := &App{
-		config: config,
+		config:         config,
+		trustedProxies: trustedProxies,
 
 		// Initialize the context pool to reduce allocations on each request.
 		pool: sync.Pool{
@@ -92,10 +367,18 @@ func New(config Config) *App {
 				return new(Context)
 			},
 		},
+	}
+	app.middleware.Store(&[]namedMiddleware{})
+	app.cache = newCache(config.Clock)
+
+	// Unmatched requests run through the same pipeline as routes.
+	app.notFound = &Route{app: app, handler: config.NotFoundHandler}
+	app.methodNotAllowed = &Route{app: app, handler: config.MethodNotAllowedHandler}
+	app.options = &Route{app: app, handler: config.OptionsHandler}
+	app.unavailable = &Route{app: app, handler: HandlerFunc(serveDraining)}
 
-		// Initialize routing components
-		mux:        http.NewServeMux(),
-		middleware: make([]MiddlewareFunc, 0),
+	if config.CBORCodec != nil {
+		app.RegisterCodec(MIMEApplicationCBOR, config.CBORCodec)
 	}
 
 	// Create HTTP server with the app as the handler
@@ -104,6 +387,7 @@ func New(config Config) *App {
 		ReadTimeout:  config.ReadTimeout,
 		WriteTimeout: config.WriteTimeout,
 		IdleTimeout:  config.IdleTimeout,
+		ConnState:    app.conns.track,
 	}
 
 	return app
@@ -0,0 +1,117 @@
+package mux
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// HeaderBaggage is the W3C Baggage propagation header.
+const HeaderBaggage = "Baggage"
+
+// Limits from the W3C Baggage specification, applied to incoming headers
+// so clients cannot attach unbounded metadata to a request.
+const (
+	maxBaggageMembers = 180
+	maxBaggageBytes   = 8192
+)
+
+// Baggage holds the W3C Baggage members of a request, keyed by member name.
+// Member properties are not retained.
+type Baggage map[string]string
+
+// Baggage returns the W3C Baggage of the request.
+// Only keys listed in Config.BaggageAllowlist are kept when the allowlist is set.
+// Malformed members are skipped and parsing stops at the specification limits.
+func (c *Context) Baggage() Baggage {
+	if c.baggage != nil {
+		return c.baggage
+	}
+
+	allowed := c.app.config.BaggageAllowlist
+	b := make(Baggage)
+	size := 0
+	for _, header := range c.req.Header.Values(HeaderBaggage) {
+		for member := range strings.SplitSeq(header, ",") {
+			size += len(member)
+			if len(b) >= maxBaggageMembers || size > maxBaggageBytes {
+				c.baggage = b
+				return b
+			}
+
+			// Drop properties, only the key and value are used.
+			member, _, _ = strings.Cut(member, ";")
+			key, value, ok := strings.Cut(member, "=")
+			if !ok {
+				continue
+			}
+			key = strings.TrimSpace(key)
+			if key == "" || (len(allowed) > 0 && !slices.Contains(allowed, key)) {
+				continue
+			}
+			value, err := url.PathUnescape(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+			b[key] = value
+		}
+	}
+
+	c.baggage = b
+	return b
+}
+
+// Select returns a copy of b holding only the given keys.
+// It is used to limit what is propagated to logs or outbound requests.
+func (b Baggage) Select(keys ...string) Baggage {
+	selected := make(Baggage, len(keys))
+	for _, key := range keys {
+		if v, ok := b[key]; ok {
+			selected[key] = v
+		}
+	}
+	return selected
+}
+
+// String encodes b as a W3C Baggage header value with members sorted by key.
+func (b Baggage) String() string {
+	keys := make([]string, 0, len(b))
+	for key := range b {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(url.PathEscape(b[key]))
+	}
+	return sb.String()
+}
+
+// Inject sets the Baggage header of an outbound request to b.
+// An empty Baggage removes the header.
+func (b Baggage) Inject(req *http.Request) {
+	if len(b) == 0 {
+		req.Header.Del(HeaderBaggage)
+		return
+	}
+	req.Header.Set(HeaderBaggage, b.String())
+}
+
+// LogValue implements slog.LogValuer, so baggage can be passed to a logger
+// as a single attribute group.
+func (b Baggage) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(b))
+	for key, value := range b {
+		attrs = append(attrs, slog.String(key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
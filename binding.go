@@ -0,0 +1,263 @@
+package mux
+
+import (
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Additional MIME types understood by Bind.
+const (
+	MIMEApplicationXML  = "application/xml"
+	MIMETextXML         = "text/xml"
+	MIMEApplicationForm = "application/x-www-form-urlencoded"
+	MIMEMultipartForm   = "multipart/form-data"
+)
+
+// bodyBinder decodes the request body of c into dest.
+type bodyBinder func(c *Context, dest any) error
+
+// binders maps request media types to their body decoders.
+var binders = map[string]bodyBinder{
+	MIMEApplicationJSON: (*Context).bindJSON,
+	MIMEApplicationXML:  (*Context).bindXML,
+	MIMETextXML:         (*Context).bindXML,
+	MIMEApplicationForm: (*Context).bindForm,
+	MIMEMultipartForm:   (*Context).bindForm,
+}
+
+// Bind decodes the request body into dest, choosing the decoder from the
+// request Content-Type. Codecs registered with App.RegisterCodec take
+// precedence over the built-in decoders. Unsupported content types produce a 415 *Error and
+// malformed bodies a 400 *Error.
+func (c *Context) Bind(dest any) error {
+	mediaType, _, err := mime.ParseMediaType(c.req.Header.Get(HeaderContentType))
+	if err != nil {
+		return wrapError(http.StatusUnsupportedMediaType, err)
+	}
+
+	if codec, ok := c.app.codec(mediaType); ok {
+		return c.instrumentBind(mediaType, func() error {
+			if err := codec.Decode(c.req.Body, dest); err != nil {
+				return bodyError(err)
+			}
+			return nil
+		})
+	}
+
+	bind, ok := binders[mediaType]
+	if !ok {
+		return NewError(http.StatusUnsupportedMediaType)
+	}
+	return c.instrumentBind(mediaType, func() error {
+		return bind(c, dest)
+	})
+}
+
+// BindJSON decodes a JSON request body into dest.
+func (c *Context) BindJSON(dest any) error {
+	return c.instrumentBind(MIMEApplicationJSON, func() error {
+		return c.bindJSON(dest)
+	})
+}
+
+// bindJSON is BindJSON without instrumentation.
+func (c *Context) bindJSON(dest any) error {
+	if err := newJSONDecoder(c.req.Body, c.jsonOptions()).Decode(dest); err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
+
+// BindXML decodes an XML request body into dest.
+func (c *Context) BindXML(dest any) error {
+	return c.instrumentBind(MIMEApplicationXML, func() error {
+		return c.bindXML(dest)
+	})
+}
+
+// bindXML is BindXML without instrumentation.
+func (c *Context) bindXML(dest any) error {
+	if err := xml.NewDecoder(c.req.Body).Decode(dest); err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
+
+// BindForm decodes an URL-encoded or multipart form body into the struct
+// pointed to by dest, matching fields by their `form` tag.
+func (c *Context) BindForm(dest any) error {
+	mediaType, _, _ := mime.ParseMediaType(c.req.Header.Get(HeaderContentType))
+	if mediaType != MIMEMultipartForm {
+		mediaType = MIMEApplicationForm
+	}
+	return c.instrumentBind(mediaType, func() error {
+		return c.bindForm(dest)
+	})
+}
+
+// bindForm is BindForm without instrumentation.
+func (c *Context) bindForm(dest any) error {
+	values, err := c.postForm()
+	if err != nil {
+		return bodyError(err)
+	}
+	return decodeValues(values, dest, "form")
+}
+
+// BindQuery decodes the query string into the struct pointed to by dest,
+// matching fields by their `query` tag.
+func (c *Context) BindQuery(dest any) error {
+	return decodeValues(c.queryValues(), dest, "query")
+}
+
+// bodyError converts a body decoding error into a 413 *Error when the body
+// limit was hit, or a 400 *Error otherwise.
+func bodyError(err error) *Error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return wrapError(http.StatusRequestEntityTooLarge, err)
+	}
+	return wrapError(http.StatusBadRequest, err)
+}
+
+// postForm parses and returns the form values of the request body.
+func (c *Context) postForm() (url.Values, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.req.Header.Get(HeaderContentType))
+	if mediaType == MIMEMultipartForm {
+		form, err := c.multipartForm()
+		if err != nil {
+			return nil, err
+		}
+		return form.Value, nil
+	}
+
+	if err := c.req.ParseForm(); err != nil {
+		return nil, err
+	}
+	return c.req.PostForm, nil
+}
+
+// textUnmarshalerType is used to detect fields decoding themselves.
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// decodeValues sets the fields of the struct pointed to by dest from values.
+// Fields are matched by the given tag, falling back to the field name.
+// A tag value of "-" skips the field. Embedded structs are decoded in place.
+func decodeValues(values url.Values, dest any, tag string) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("mux: bind destination must be a non-nil pointer to a struct")
+	}
+	return decodeStruct(values, v.Elem(), tag)
+}
+
+// decodeStruct decodes values into the struct value v.
+func decodeStruct(values url.Values, v reflect.Value, tag string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Tag.Get(tag)
+		if name == "-" {
+			continue
+		}
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := decodeStruct(values, v.Field(i), tag); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		raw, ok := values[name]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		if err := setField(v.Field(i), raw); err != nil {
+			return wrapError(http.StatusBadRequest, fmt.Errorf("field %q: %w", name, err))
+		}
+	}
+	return nil
+}
+
+// setField assigns raw to the field f, converting to its type.
+// Slices receive every value, other types the first one.
+func setField(f reflect.Value, raw []string) error {
+	if f.Kind() == reflect.Slice && !f.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(f.Type(), len(raw), len(raw))
+		for i, s := range raw {
+			if err := setValue(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		f.Set(slice)
+		return nil
+	}
+	return setValue(f, raw[0])
+}
+
+// setValue assigns the string s to v, converting to its type.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), s)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeFor[time.Duration]() {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package mux
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrParseTimeout is the error of binds whose body reads go on past
+// Config.MaxParseDuration.
+// It surfaces as the Err of a 400 *Error.
+var ErrParseTimeout = errors.New("mux: request body parsing took too long")
+
+// BindStats holds the body decoding counters of a content type.
+type BindStats struct {
+	// Count is the number of decoded bodies.
+	Count uint64 `json:"count"`
+
+	// Errors is the number of bodies that failed to decode.
+	Errors uint64 `json:"errors"`
+
+	// Bytes is the total number of body bytes read.
+	Bytes uint64 `json:"bytes"`
+
+	// Duration is the total time spent decoding.
+	Duration time.Duration `json:"duration"`
+
+	// MaxDuration is the longest time spent decoding a single body.
+	MaxDuration time.Duration `json:"max_duration"`
+}
+
+// bindTracker maintains BindStats per content type.
+type bindTracker struct {
+	// mutex protects stats.
+	mutex sync.Mutex
+
+	// stats maps media types to their counters.
+	stats map[string]*BindStats
+}
+
+// record accounts for a decoded body.
+func (t *bindTracker) record(mediaType string, n int64, d time.Duration, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.stats == nil {
+		t.stats = make(map[string]*BindStats)
+	}
+	s := t.stats[mediaType]
+	if s == nil {
+		s = &BindStats{}
+		t.stats[mediaType] = s
+	}
+	s.Count++
+	if err != nil {
+		s.Errors++
+	}
+	s.Bytes += uint64(n)
+	s.Duration += d
+	s.MaxDuration = max(s.MaxDuration, d)
+}
+
+// snapshot returns a copy of the counters.
+func (t *bindTracker) snapshot() map[string]BindStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	stats := make(map[string]BindStats, len(t.stats))
+	for mediaType, s := range t.stats {
+		stats[mediaType] = *s
+	}
+	return stats
+}
+
+// bindBody counts the bytes read from the request body and fails reads
+// once the parse deadline has passed.
+type bindBody struct {
+	io.ReadCloser
+
+	// n is the number of bytes read.
+	n int64
+
+	// clock is Config.Clock, and deadline the end of the parse budget,
+	// zero without one.
+	clock    Clock
+	deadline time.Time
+}
+
+// Read implements io.Reader.
+func (b *bindBody) Read(p []byte) (int, error) {
+	if !b.deadline.IsZero() && b.clock.Now().After(b.deadline) {
+		return 0, ErrParseTimeout
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// instrumentBind runs bind with the request body wrapped in a bindBody and
+// records its metrics under mediaType.
+func (c *Context) instrumentBind(mediaType string, bind func() error) error {
+	clock := c.app.config.Clock
+	start := clock.Now()
+	body := &bindBody{ReadCloser: c.req.Body, clock: clock}
+	if d := c.app.config.MaxParseDuration; d > 0 {
+		body.deadline = start.Add(d)
+	}
+
+	c.req.Body = body
+	err := bind()
+	c.req.Body = body.ReadCloser
+
+	c.app.binds.record(mediaType, body.n, clock.Now().Sub(start), err)
+	return err
+}
@@ -0,0 +1,148 @@
+package mux
+
+import (
+	"bytes"
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// bufferClasses are the capacity tiers of pooled response buffers.
+// Buffers are handed out from the smallest tier that fits the requested size.
+var bufferClasses = [...]int{4 << 10, 16 << 10, 64 << 10, 256 << 10}
+
+// maxRetainedBuffer is the largest buffer capacity kept for reuse.
+// Bigger buffers are left to the garbage collector so a single large
+// payload does not pin memory in the pool.
+const maxRetainedBuffer = 1 << 20
+
+// BufferPoolStats is a snapshot of the response buffer pool counters.
+type BufferPoolStats struct {
+	// Gets is the number of buffers handed out.
+	Gets uint64 `json:"gets"`
+
+	// Allocs is the number of buffers newly allocated because the pool was empty.
+	Allocs uint64 `json:"allocs"`
+
+	// Puts is the number of buffers returned to the pool.
+	Puts uint64 `json:"puts"`
+
+	// Discards is the number of buffers dropped for exceeding the retained size.
+	Discards uint64 `json:"discards"`
+}
+
+// bufferPool manages tiered pools of byte buffers used by the JSON, HTML
+// and compression response paths.
+type bufferPool struct {
+	tiers [len(bufferClasses)]sync.Pool
+
+	gets     atomic.Uint64
+	allocs   atomic.Uint64
+	puts     atomic.Uint64
+	discards atomic.Uint64
+}
+
+// buffers is the process-wide response buffer pool.
+var buffers = newBufferPool()
+
+func init() {
+	expvar.Publish("mux.buffer_pool", expvar.Func(func() any {
+		return buffers.stats()
+	}))
+}
+
+// newBufferPool creates a bufferPool with one sync.Pool per size class.
+func newBufferPool() *bufferPool {
+	p := &bufferPool{}
+	for i, size := range bufferClasses {
+		p.tiers[i].New = func() any {
+			p.allocs.Add(1)
+			return bytes.NewBuffer(make([]byte, 0, size))
+		}
+	}
+	return p
+}
+
+// get returns an empty buffer with a capacity of at least size bytes when
+// size fits a tier; larger requests get a buffer from the biggest tier.
+func (p *bufferPool) get(size int) *bytes.Buffer {
+	p.gets.Add(1)
+	for i, class := range bufferClasses {
+		if size <= class {
+			return p.tiers[i].Get().(*bytes.Buffer)
+		}
+	}
+	return p.tiers[len(bufferClasses)-1].Get().(*bytes.Buffer)
+}
+
+// put resets buf and returns it to the tier matching its capacity.
+func (p *bufferPool) put(buf *bytes.Buffer) {
+	c := buf.Cap()
+	if c > maxRetainedBuffer || c < bufferClasses[0] {
+		p.discards.Add(1)
+		return
+	}
+	buf.Reset()
+
+	// Pick the largest tier the buffer can fully serve.
+	tier := 0
+	for i, class := range bufferClasses {
+		if c >= class {
+			tier = i
+		}
+	}
+	p.puts.Add(1)
+	p.tiers[tier].Put(buf)
+}
+
+// stats returns a snapshot of the pool counters.
+func (p *bufferPool) stats() BufferPoolStats {
+	return BufferPoolStats{
+		Gets:     p.gets.Load(),
+		Allocs:   p.allocs.Load(),
+		Puts:     p.puts.Load(),
+		Discards: p.discards.Load(),
+	}
+}
+
+// AcquireBuffer gets an empty buffer from the response buffer pool, from
+// the tier fitting size, the expected number of bytes. Middleware buffering
+// responses use it so their buffers share the pool limits. Return the
+// buffer with ReleaseBuffer.
+func AcquireBuffer(size int) *bytes.Buffer {
+	return buffers.get(size)
+}
+
+// ReleaseBuffer returns a buffer obtained from AcquireBuffer to the pool.
+// Buffers over the retained size are dropped. The buffer must not be used
+// afterwards.
+func ReleaseBuffer(buf *bytes.Buffer) {
+	buffers.put(buf)
+}
+
+// bufferSite is a call site of the buffer pool. It remembers the size
+// its last buffer grew to, so the next one comes from the tier that fits
+// without growing again.
+type bufferSite struct {
+	size atomic.Int64
+}
+
+// Buffer sites of the response paths.
+var (
+	jsonBuffers    bufferSite
+	cborBuffers    bufferSite
+	codecBuffers   bufferSite
+	feedBuffers    bufferSite
+	dirListBuffers bufferSite
+)
+
+// acquire gets an empty buffer sized for the site.
+func (s *bufferSite) acquire() *bytes.Buffer {
+	return buffers.get(int(s.size.Load()))
+}
+
+// release records the size of buf and returns it to the pool.
+func (s *bufferSite) release(buf *bytes.Buffer) {
+	s.size.Store(int64(buf.Len()))
+	buffers.put(buf)
+}
@@ -0,0 +1,174 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Memo returns the value cached under key for the request, calling loader
+// on first use. Handlers and middleware sharing a key, e.g. "user", then
+// load it once per request. Errors are not cached, so a failed load is
+// retried by the next call. Like the rest of Context, Memo is not safe for
+// concurrent use.
+func (c *Context) Memo(key string, loader func() (any, error)) (any, error) {
+	if v, ok := c.memo[key]; ok {
+		return v, nil
+	}
+	v, err := loader()
+	if err != nil {
+		return nil, err
+	}
+	if c.memo == nil {
+		c.memo = make(map[string]any)
+	}
+	c.memo[key] = v
+	return v, nil
+}
+
+// Cache is the app-level cache returned by App.Cache, holding values
+// shared across requests for a limited time. It is safe for concurrent
+// use.
+type Cache struct {
+	// clock is Config.Clock, for expiry.
+	clock Clock
+
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+
+	// writes counts stores since expired entries were last swept.
+	writes int
+}
+
+// cacheEntry is a cached value, or a load in progress while done is open.
+type cacheEntry struct {
+	value any
+
+	// expires is the expiry time, zero for values that do not expire.
+	expires time.Time
+
+	// done is closed when the load of the value finished, with err set
+	// if it failed.
+	done chan struct{}
+	err  error
+}
+
+// errCacheLoaderPanicked is returned to the callers waiting for a load
+// whose loader panicked.
+var errCacheLoaderPanicked = errors.New("mux: cache loader panicked")
+
+// cacheSweepInterval is the number of stores between sweeps of the
+// expired entries.
+const cacheSweepInterval = 1024
+
+// newCache creates an empty Cache.
+func newCache(clock Clock) *Cache {
+	return &Cache{clock: clock, entries: make(map[string]*cacheEntry)}
+}
+
+// Cache returns the cache shared by the handlers of the app.
+func (app *App) Cache() *Cache {
+	return app.cache
+}
+
+// Get returns the value cached under key, if any and not expired.
+func (c *Cache) Get(key string) (any, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.done != nil || c.expired(e) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set caches value under key for ttl, or without expiry if ttl is not
+// positive.
+func (c *Cache) Set(key string, value any, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.store(key, &cacheEntry{value: value, expires: c.expiry(ttl)})
+}
+
+// Delete removes the value cached under key. A load in progress still
+// completes for its callers but is not cached.
+func (c *Cache) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Load returns the value cached under key, calling loader and caching its
+// result for ttl on a miss. Concurrent misses for the same key share a
+// single call of loader. Errors are returned to every waiting caller and
+// are not cached. Waiting stops with ctx.Err() when ctx is done.
+func (c *Cache) Load(ctx context.Context, key string, ttl time.Duration, loader func() (any, error)) (any, error) {
+	c.mutex.Lock()
+	if e, ok := c.entries[key]; ok && !c.expired(e) {
+		c.mutex.Unlock()
+		if e.done == nil {
+			return e.value, nil
+		}
+		select {
+		case <-e.done:
+			return e.value, e.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	e := &cacheEntry{done: make(chan struct{})}
+	c.store(key, e)
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		if c.entries[key] == e {
+			if e.err != nil {
+				delete(c.entries, key)
+			} else {
+				c.entries[key] = &cacheEntry{value: e.value, expires: c.expiry(ttl)}
+			}
+		}
+		c.mutex.Unlock()
+		close(e.done)
+	}()
+
+	// Waiters see errCacheLoaderPanicked if loader panics.
+	e.err = errCacheLoaderPanicked
+	e.value, e.err = loader()
+	return e.value, e.err
+}
+
+// store caches e under key, sweeping expired entries from time to time.
+// The caller must hold c.mutex.
+func (c *Cache) store(key string, e *cacheEntry) {
+	c.entries[key] = e
+	c.writes++
+	if c.writes < cacheSweepInterval {
+		return
+	}
+	c.writes = 0
+	for key, e := range c.entries {
+		if e.done == nil && c.expired(e) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// expiry returns the expiry time of a value cached for ttl.
+func (c *Cache) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return c.clock.Now().Add(ttl)
+}
+
+// expired reports whether the value of e expired.
+// The caller must hold c.mutex.
+func (c *Cache) expired(e *cacheEntry) bool {
+	return !e.expires.IsZero() && !c.clock.Now().Before(e.expires)
+}
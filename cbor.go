@@ -0,0 +1,46 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+)
+
+// MIMEApplicationCBOR is the media type of CBOR (RFC 8949) bodies.
+const MIMEApplicationCBOR = "application/cbor"
+
+// ErrCBORUnavailable is returned by the CBOR helpers when Config.CBORCodec is not set.
+var ErrCBORUnavailable = errors.New("mux: no CBOR codec configured")
+
+// CBOR encodes v with Config.CBORCodec and writes it with the given status code.
+func (c *Context) CBOR(status int, v any) error {
+	codec := c.app.config.CBORCodec
+	if codec == nil {
+		return ErrCBORUnavailable
+	}
+
+	buf := cborBuffers.acquire()
+	defer cborBuffers.release(buf)
+
+	if err := codec.Encode(buf, v); err != nil {
+		return err
+	}
+
+	c.res.Header().Set(HeaderContentType, MIMEApplicationCBOR)
+	c.res.WriteHeader(status)
+	_, err := c.res.Write(buf.Bytes())
+	return err
+}
+
+// BindCBOR decodes a CBOR request body into dest with Config.CBORCodec.
+func (c *Context) BindCBOR(dest any) error {
+	codec := c.app.config.CBORCodec
+	if codec == nil {
+		return wrapError(http.StatusUnsupportedMediaType, ErrCBORUnavailable)
+	}
+	return c.instrumentBind(MIMEApplicationCBOR, func() error {
+		if err := codec.Decode(c.req.Body, dest); err != nil {
+			return bodyError(err)
+		}
+		return nil
+	})
+}
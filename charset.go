@@ -0,0 +1,155 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HeaderAcceptCharset is the request header used for charset negotiation.
+const HeaderAcceptCharset = "Accept-Charset"
+
+// Charsets supported for transcoding.
+const (
+	CharsetUTF8     = "utf-8"
+	CharsetISO88591 = "iso-8859-1"
+	CharsetASCII    = "us-ascii"
+)
+
+// ErrUnsupportedCharset is returned for charsets other than CharsetUTF8,
+// CharsetISO88591 and CharsetASCII.
+var ErrUnsupportedCharset = errors.New("mux: unsupported charset")
+
+// charsetAliases maps the accepted charset labels to their canonical names.
+var charsetAliases = map[string]string{
+	"utf-8":      CharsetUTF8,
+	"utf8":       CharsetUTF8,
+	"iso-8859-1": CharsetISO88591,
+	"iso8859-1":  CharsetISO88591,
+	"iso_8859-1": CharsetISO88591,
+	"latin1":     CharsetISO88591,
+	"l1":         CharsetISO88591,
+	"us-ascii":   CharsetASCII,
+	"ascii":      CharsetASCII,
+}
+
+// canonicalCharset returns the canonical name of a supported charset label.
+func canonicalCharset(label string) (string, bool) {
+	charset, ok := charsetAliases[strings.ToLower(strings.TrimSpace(label))]
+	return charset, ok
+}
+
+// EncodeString encodes the UTF-8 string s in charset. Runes the charset
+// cannot represent are replaced with '?'.
+func EncodeString(s, charset string) ([]byte, error) {
+	charset, ok := canonicalCharset(charset)
+	if !ok {
+		return nil, ErrUnsupportedCharset
+	}
+	if charset == CharsetUTF8 {
+		return []byte(s), nil
+	}
+
+	limit := rune(0xFF)
+	if charset == CharsetASCII {
+		limit = 0x7F
+	}
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > limit {
+			r = '?'
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
+
+// DecodeString decodes b from charset into a UTF-8 string, e.g. a form
+// value posted by a legacy client. Invalid UTF-8 and ASCII bytes are
+// replaced with U+FFFD.
+func DecodeString(b []byte, charset string) (string, error) {
+	charset, ok := canonicalCharset(charset)
+	if !ok {
+		return "", ErrUnsupportedCharset
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(b))
+	switch charset {
+	case CharsetUTF8:
+		return strings.ToValidUTF8(string(b), string(utf8.RuneError)), nil
+	case CharsetISO88591:
+		for _, c := range b {
+			sb.WriteRune(rune(c))
+		}
+	case CharsetASCII:
+		for _, c := range b {
+			if c > 0x7F {
+				sb.WriteRune(utf8.RuneError)
+				continue
+			}
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// Charset returns the response charset negotiated from the Accept-Charset
+// header among the supported charsets. Config.DefaultCharset wins ties and
+// is used when the header is missing or accepts no supported charset.
+func (c *Context) Charset() string {
+	preferred := c.app.config.DefaultCharset
+	accept := c.req.Header.Get(HeaderAcceptCharset)
+	if accept == "" {
+		return preferred
+	}
+
+	best, bestQ := preferred, 0.0
+	wildcard := -1.0
+	for part := range strings.SplitSeq(accept, ",") {
+		label, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		label = strings.TrimSpace(label)
+		if label == "*" {
+			wildcard = q
+			continue
+		}
+		charset, ok := canonicalCharset(label)
+		if !ok || q <= 0 {
+			continue
+		}
+		if q > bestQ || q == bestQ && charset == preferred {
+			best, bestQ = charset, q
+		}
+	}
+	if wildcard > bestQ {
+		return preferred
+	}
+	return best
+}
+
+// withCharset adds a charset parameter to text content types without one.
+// JSON is always UTF-8 and left as is.
+func withCharset(contentType, charset string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if strings.Contains(strings.ToLower(contentType), "charset=") || !textMediaType(mediaType) {
+		return contentType
+	}
+	return fmt.Sprintf("%s; charset=%s", contentType, charset)
+}
+
+// textMediaType reports whether mediaType carries text in a charset.
+func textMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == MIMEApplicationXML || strings.HasSuffix(mediaType, "+xml")
+}
@@ -0,0 +1,48 @@
+package mux
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time. Time-based features read it instead of the time
+// package, so tests can substitute a fake clock through Config.Clock
+// instead of sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for d to elapse and then sends the current time.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock backed by the time package.
+var SystemClock Clock = systemClock{}
+
+// systemClock implements Clock with the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clockKey is the request context key holding a non-default Clock.
+type clockKey struct{}
+
+// WithClock returns a copy of ctx carrying clock.
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, clock)
+}
+
+// ClockFrom returns the Clock carried by ctx, which is Config.Clock for
+// request contexts, or SystemClock if there is none.
+func ClockFrom(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return clock
+	}
+	return SystemClock
+}
+
+// Clock returns the application Clock.
+func (c *Context) Clock() Clock {
+	return c.app.config.Clock
+}
@@ -0,0 +1,220 @@
+package mux
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HeaderAccept is the request header used for response negotiation.
+const HeaderAccept = "Accept"
+
+// MIMEOctetStream is the generic binary media type.
+const MIMEOctetStream = "application/octet-stream"
+
+// Codec encodes and decodes values for a media type.
+type Codec interface {
+	// Encode writes v to w.
+	Encode(w io.Writer, v any) error
+
+	// Decode reads r into v.
+	Decode(r io.Reader, v any) error
+}
+
+// JSONCodec is the Codec for JSON.
+var JSONCodec Codec = jsonCodec{}
+
+// XMLCodec is the Codec for XML.
+var XMLCodec Codec = xmlCodec{}
+
+// BinaryCodec is a Codec for []byte-backed types. It encodes []byte and
+// encoding.BinaryMarshaler values and decodes into *[]byte and
+// encoding.BinaryUnmarshaler values. It suits protocols like
+// application/dns-message where the body is an opaque binary message.
+var BinaryCodec Codec = binaryCodec{}
+
+// RegisterCodec registers codec for mediaType. Bind uses it to decode request
+// bodies of that type and Respond offers it during negotiation.
+// A registered codec takes precedence over the built-in decoders.
+func (app *App) RegisterCodec(mediaType string, codec Codec) {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	// Copy on write, requests may be reading the current map.
+	codecs := make(map[string]Codec)
+	if current := app.codecs.Load(); current != nil {
+		maps.Copy(codecs, *current)
+	}
+	codecs[strings.ToLower(mediaType)] = codec
+	app.codecs.Store(&codecs)
+}
+
+// codec returns the codec registered for mediaType, if any.
+func (app *App) codec(mediaType string) (Codec, bool) {
+	codecs := app.codecs.Load()
+	if codecs == nil {
+		return nil, false
+	}
+	codec, ok := (*codecs)[mediaType]
+	return codec, ok
+}
+
+// Respond encodes v with the codec negotiated from the Accept header and
+// writes it with the given status code. Registered codecs are offered in
+// addition to JSON and XML; JSON is used when the client accepts anything.
+// Routes declaring Produces offer those media types only.
+// If no offered type is acceptable, a 406 *Error is returned.
+func (c *Context) Respond(status int, v any) error {
+	offers := []string{MIMEApplicationJSON, MIMEApplicationXML}
+	if c.route != nil && len(c.route.produces) > 0 {
+		offers = c.route.produces
+	} else if codecs := c.app.codecs.Load(); codecs != nil {
+		offers = append(offers, sortedKeys(*codecs)...)
+	}
+
+	mediaType := negotiate(c.req.Header.Get(HeaderAccept), offers)
+	if mediaType == "" {
+		return NewError(http.StatusNotAcceptable)
+	}
+
+	codec, ok := c.app.codec(mediaType)
+	if !ok {
+		switch mediaType {
+		case MIMEApplicationJSON:
+			codec = JSONCodec
+		case MIMEApplicationXML, MIMETextXML:
+			codec = XMLCodec
+		default:
+			return fmt.Errorf("mux: no codec registered for %s", mediaType)
+		}
+	}
+
+	buf := codecBuffers.acquire()
+	defer codecBuffers.release(buf)
+
+	if err := codec.Encode(buf, v); err != nil {
+		return err
+	}
+
+	c.res.Header().Set(HeaderContentType, withCharset(mediaType, CharsetUTF8))
+	c.res.WriteHeader(status)
+	_, err := c.res.Write(buf.Bytes())
+	return err
+}
+
+// negotiate returns the offer best matching the Accept header value, or ""
+// if none is acceptable. An empty header accepts the first offer.
+func negotiate(accept string, offers []string) string {
+	if accept == "" {
+		return offers[0]
+	}
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		for _, offer := range offers {
+			specificity := matchMediaType(mediaType, offer)
+			if specificity < 0 {
+				continue
+			}
+			// Prefer the higher quality, then the more specific range.
+			if q > bestQ || q == bestQ && specificity > bestSpecificity {
+				best, bestQ, bestSpecificity = offer, q, specificity
+			}
+			break
+		}
+	}
+	return best
+}
+
+// matchMediaType reports how specifically the media range matches offer:
+// 2 for an exact match, 1 for type/*, 0 for */*, and -1 for no match.
+func matchMediaType(mediaRange, offer string) int {
+	switch {
+	case mediaRange == offer:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, mediaRange[:len(mediaRange)-1]):
+		return 1
+	}
+	return -1
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonCodec implements Codec with encoding/json.
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+func (jsonCodec) Decode(r io.Reader, v any) error { return json.NewDecoder(r).Decode(v) }
+
+// xmlCodec implements Codec with encoding/xml.
+type xmlCodec struct{}
+
+func (xmlCodec) Encode(w io.Writer, v any) error { return xml.NewEncoder(w).Encode(v) }
+func (xmlCodec) Decode(r io.Reader, v any) error { return xml.NewDecoder(r).Decode(v) }
+
+// binaryCodec implements Codec for []byte-backed types.
+type binaryCodec struct{}
+
+func (binaryCodec) Encode(w io.Writer, v any) error {
+	switch v := v.(type) {
+	case []byte:
+		_, err := w.Write(v)
+		return err
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+	return fmt.Errorf("mux: cannot binary encode %T", v)
+}
+
+func (binaryCodec) Decode(r io.Reader, v any) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case *[]byte:
+		*v = b
+		return nil
+	case encoding.BinaryUnmarshaler:
+		return v.UnmarshalBinary(b)
+	}
+	return fmt.Errorf("mux: cannot binary decode into %T", v)
+}
@@ -0,0 +1,78 @@
+package mux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Constraint reports whether a path segment is an acceptable value for a
+// constrained wildcard. Requests with segments it rejects fall through to
+// other routes, or get a 404.
+type Constraint func(segment string) bool
+
+// builtinConstraints are the named constraints available in every pattern,
+// e.g. "/users/{id:int}".
+var builtinConstraints = map[string]Constraint{
+	// int is a base 10 integer fitting in an int64, optionally signed.
+	"int": func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	},
+	// uint is a base 10 unsigned integer fitting in an uint64.
+	"uint": func(s string) bool {
+		_, err := strconv.ParseUint(s, 10, 64)
+		return err == nil && s[0] != '+'
+	},
+	"alpha": regexp.MustCompile(`^[A-Za-z]+$`).MatchString,
+	"alnum": regexp.MustCompile(`^[A-Za-z0-9]+$`).MatchString,
+	"hex":   regexp.MustCompile(`^[0-9A-Fa-f]+$`).MatchString,
+	"slug":  regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`).MatchString,
+	"uuid":  isUUID,
+}
+
+// RegisterConstraint makes fn available to patterns as the named
+// constraint name, e.g. {code:country} after
+// RegisterConstraint("country", isCountryCode). Built-in constraints are
+// int, uint, alpha, alnum, hex, slug and uuid; registering one of those
+// names replaces it. Constraints must be registered before the routes
+// using them.
+func (app *App) RegisterConstraint(name string, fn Constraint) {
+	if !validWildcardName(name) {
+		panic(fmt.Sprintf("mux: bad constraint name %q", name))
+	}
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	for _, rt := range app.routers() {
+		rt.mutex.Lock()
+		if rt.constraints == nil {
+			rt.constraints = make(map[string]Constraint)
+		}
+		rt.constraints[name] = fn
+		rt.mutex.Unlock()
+	}
+}
+
+// constraint returns the Constraint for the constraint source src of a
+// pattern: a constraint name, or else a regular expression the whole
+// segment must match. The caller must hold rt.mutex.
+func (rt *router) constraint(src string) (Constraint, error) {
+	if fn, ok := rt.constraints[src]; ok {
+		return fn, nil
+	}
+	if fn, ok := builtinConstraints[src]; ok {
+		return fn, nil
+	}
+	if validWildcardName(src) {
+		// Plain words are taken for names, so typos do not silently
+		// become literal regular expressions.
+		return nil, fmt.Errorf("unknown constraint %q", src)
+	}
+
+	if _, err := regexp.Compile(src); err != nil {
+		return nil, err
+	}
+	return regexp.MustCompile("^(?:" + src + ")$").MatchString, nil
+}
@@ -0,0 +1,61 @@
+package mux
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Consumes restricts the request bodies accepted by the route to the given
+// media types, e.g. "application/json" or "image/*". Requests with a body
+// of another Content-Type are answered with a 415 *Error before the
+// handler runs. Requests without a body are not checked.
+func (r *Route) Consumes(mediaTypes ...string) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	r.consumes = append(r.consumes, lowerAll(mediaTypes)...)
+	return r
+}
+
+// Produces declares the media types the route responds with, in order of
+// preference. Respond negotiates among them instead of the registered
+// codecs.
+func (r *Route) Produces(mediaTypes ...string) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	r.produces = append(r.produces, lowerAll(mediaTypes)...)
+	return r
+}
+
+// checkConsumes enforces Consumes on the request of ctx.
+func (r *Route) checkConsumes(ctx *Context) error {
+	if len(r.consumes) == 0 {
+		return nil
+	}
+	req := ctx.req
+	if req.ContentLength == 0 || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get(HeaderContentType))
+	if err != nil {
+		return wrapError(http.StatusUnsupportedMediaType, err)
+	}
+	for _, accepted := range r.consumes {
+		if matchMediaType(accepted, mediaType) >= 0 {
+			return nil
+		}
+	}
+	return NewError(http.StatusUnsupportedMediaType)
+}
+
+// lowerAll returns the lower-cased copies of values.
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
@@ -0,0 +1,256 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ContextKey is the type of the keys under which Context values are mirrored
+// into the request's context.Context.
+type ContextKey string
+
+// Set stores a request-scoped value under key.
+// The value is also attached to the request's context.Context under
+// ContextKey(Config.ContextKeyNamespace + key), so libraries reading from
+// r.Context() can see it.
+func (c *Context) Set(key string, value any) {
+	if c.locals == nil {
+		c.locals = make(map[string]any)
+	}
+	c.locals[key] = value
+	c.req = c.req.WithContext(context.WithValue(c.req.Context(), c.contextKey(key), value))
+}
+
+// Get returns the request-scoped value stored under key.
+// If the value was not set through Set, Get falls back to the request's
+// context.Context, so values placed there by net/http middleware are visible too.
+func (c *Context) Get(key string) any {
+	if v, ok := c.local(key); ok {
+		return v
+	}
+	return c.req.Context().Value(c.contextKey(key))
+}
+
+// local returns the value stored under key for the request, falling back
+// to the values declared on the route with Route.WithValue.
+func (c *Context) local(key string) (any, bool) {
+	if v, ok := c.locals[key]; ok {
+		return v, true
+	}
+	if c.route != nil {
+		v, ok := c.route.values[key]
+		return v, ok
+	}
+	return nil, false
+}
+
+// Locals gets or sets a request-scoped value without touching the request's
+// context.Context: Locals(key) returns the value stored under key, and
+// Locals(key, value) stores value and returns it. It is the cheap way for
+// middleware to hand data, e.g. the authenticated user, to later handlers.
+// Locals shares its storage with Set, falls back to the values declared
+// with Route.WithValue, and values are dropped when the request ends.
+func (c *Context) Locals(key string, value ...any) any {
+	if len(value) == 0 {
+		v, _ := c.local(key)
+		return v
+	}
+	if c.locals == nil {
+		c.locals = make(map[string]any)
+	}
+	c.locals[key] = value[0]
+	return value[0]
+}
+
+// contextKey returns the namespaced request context key for key.
+func (c *Context) contextKey(key string) ContextKey {
+	return ContextKey(c.app.config.ContextKeyNamespace + key)
+}
+
+// Request returns the underlying *http.Request.
+func (c *Context) Request() *http.Request {
+	return c.req
+}
+
+// SetRequest replaces the underlying *http.Request, for example after
+// deriving a new request context. Subsequent middleware and handlers see r.
+func (c *Context) SetRequest(r *http.Request) {
+	c.req = r
+}
+
+// CloneRequest returns a deep copy of the request with its own body, for
+// mirroring, auditing or retrying it. The body is read into memory once,
+// within Config.BodyLimit, and both the request and the copies stay
+// readable from the start; GetBody is set on them. A body over the limit
+// produces a 413 *Error.
+func (c *Context) CloneRequest() (*http.Request, error) {
+	body, err := c.bufferBody()
+	if err != nil {
+		return nil, err
+	}
+
+	r := c.req.Clone(c.req.Context())
+	if body != nil {
+		r.Body = body.reader()
+		r.GetBody = body.getBody
+		r.ContentLength = int64(len(body.data))
+	}
+	return r, nil
+}
+
+// bufferBody reads the request body into memory, replacing it with a
+// replayable one. It returns nil for requests without a body.
+func (c *Context) bufferBody() (*replayBody, error) {
+	req := c.req
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if body, ok := req.Body.(*replayBody); ok {
+		return body, nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, bodyError(err)
+	}
+	body := &replayBody{data: data}
+	body.Reader.Reset(data)
+	req.Body = body
+	req.GetBody = body.getBody
+	return body, nil
+}
+
+// replayBody is a request body held in memory.
+type replayBody struct {
+	bytes.Reader
+	data []byte
+}
+
+// Close implements io.Closer.
+func (b *replayBody) Close() error { return nil }
+
+// reader returns a new body reading data from the start.
+func (b *replayBody) reader() io.ReadCloser {
+	return io.NopCloser(bytes.NewReader(b.data))
+}
+
+// getBody implements http.Request.GetBody.
+func (b *replayBody) getBody() (io.ReadCloser, error) {
+	return b.reader(), nil
+}
+
+// OriginalURL returns the request target as received from the client,
+// path and query, unaffected by SetPath, QueryArgs changes or Forward.
+func (c *Context) OriginalURL() string {
+	if c.req.RequestURI != "" {
+		return c.req.RequestURI
+	}
+	return c.req.URL.RequestURI()
+}
+
+// Path returns the unescaped request path, including any rewrite made
+// with SetPath.
+func (c *Context) Path() string {
+	return c.req.URL.Path
+}
+
+// SetPath rewrites the request path seen by the rest of the chain, e.g.
+// before proxying to an upstream with a different layout. The request is
+// not routed again; use Forward to dispatch it to another route.
+func (c *Context) SetPath(path string) {
+	c.req.URL.Path = path
+	c.req.URL.RawPath = ""
+}
+
+// Response returns the underlying http.ResponseWriter.
+// Writing through it directly, e.g. to hijack the connection, bypasses mux helpers.
+func (c *Context) Response() http.ResponseWriter {
+	return c.res
+}
+
+// SetResponse replaces the http.ResponseWriter used by the rest of the
+// chain, e.g. to buffer or transform the response. Response status and size
+// reporting keep tracking what reaches the original writer.
+func (c *Context) SetResponse(w http.ResponseWriter) {
+	c.res = w
+}
+
+// ErrRouteNotFound is returned by Forward when no route matches the target.
+var ErrRouteNotFound = errors.New("mux: route not found")
+
+// Forward re-dispatches the current request to the route registered for
+// method and path, without a client round trip. path may carry a query string.
+// Only the middleware chain and handler of the target run; the hooks, the
+// ErrorHandler and the statistics stay with the current request, so the
+// error of the target is returned to be handled like any other. Values
+// stored with Set remain visible through the request context.
+func (c *Context) Forward(method, path string) error {
+	target, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+
+	r := c.req.Clone(c.req.Context())
+	r.Method = method
+	r.URL.Path = target.Path
+	r.URL.RawPath = target.RawPath
+	if target.RawQuery != "" {
+		r.URL.RawQuery = target.RawQuery
+	}
+
+	m, ok := c.app.lookup(requestHost(r), r.Method, r.URL.EscapedPath())
+	if !ok {
+		return ErrRouteNotFound
+	}
+	return m.forward(c, r)
+}
+
+// HandleError runs the ErrorHandler of the route for err right away, so that
+// middleware can observe the resulting response, e.g. to log its status.
+// The middleware should still return err; the dispatcher then accounts for
+// it without running the ErrorHandler again.
+func (c *Context) HandleError(err error) {
+	if err == nil || c.errorHandled {
+		return
+	}
+	c.errorHandled = true
+	c.app.errorHandler(c)(c, err)
+}
+
+// ResponseStatus returns the status code of the response sent so far,
+// defaulting to 200 if nothing was written yet.
+func (c *Context) ResponseStatus() int {
+	return c.writer.Status()
+}
+
+// ResponseSize returns the number of response body bytes written so far.
+func (c *Context) ResponseSize() int64 {
+	return c.writer.size
+}
+
+// onReturn registers fn to run as soon as the handler returns, before the
+// ErrorHandler and the response hooks write to the response, e.g. to stop
+// goroutines writing to it.
+func (c *Context) onReturn(fn func()) {
+	c.returnHooks = append(c.returnHooks, fn)
+}
+
+// handlerReturned runs the hooks registered with onReturn once.
+func (c *Context) handlerReturned() {
+	for _, fn := range c.returnHooks {
+		fn()
+	}
+	clear(c.returnHooks)
+	c.returnHooks = c.returnHooks[:0]
+}
+
+// onRelease registers fn to run when the request is done and the Context
+// is about to be reused, e.g. to remove temporary files.
+func (c *Context) onRelease(fn func()) {
+	c.releaseHooks = append(c.releaseHooks, fn)
+}
@@ -0,0 +1,100 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cookie is a response cookie set through Context.SetCookie.
+// Unset attributes are filled from the Cookie defaults in Config.
+type Cookie struct {
+	Name   string
+	Value  string
+	Path   string
+	Domain string
+
+	// MaxAge is the lifetime in seconds. Zero uses Config.CookieMaxAge,
+	// a negative value deletes the cookie.
+	MaxAge int
+
+	// Expires is the absolute expiry, for clients ignoring Max-Age.
+	Expires time.Time
+
+	// SessionOnly sends neither Max-Age nor Expires, ignoring the defaults.
+	SessionOnly bool
+
+	Secure   bool
+	HTTPOnly bool
+
+	// SameSite is "Lax", "Strict" or "None". Empty uses Config.CookieSameSite.
+	SameSite string
+
+	// Partitioned opts the cookie into partitioned storage (CHIPS).
+	// It requires Secure.
+	Partitioned bool
+}
+
+// Cookie returns the value of the request cookie name, or "" if absent.
+func (c *Context) Cookie(name string) string {
+	cookie, err := c.req.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// SetCookie adds a Set-Cookie header for cookie to the response.
+func (c *Context) SetCookie(cookie *Cookie) {
+	cfg := &c.app.config
+
+	hc := &http.Cookie{
+		Name:        cookie.Name,
+		Value:       cookie.Value,
+		Path:        cookie.Path,
+		Domain:      cookie.Domain,
+		MaxAge:      cookie.MaxAge,
+		Expires:     cookie.Expires,
+		Secure:      cookie.Secure || cfg.CookieSecure,
+		HttpOnly:    cookie.HTTPOnly || cfg.CookieHTTPOnly,
+		Partitioned: cookie.Partitioned,
+	}
+	if hc.Path == "" {
+		hc.Path = "/"
+	}
+	if cookie.SessionOnly {
+		hc.MaxAge, hc.Expires = 0, time.Time{}
+	} else if hc.MaxAge == 0 {
+		hc.MaxAge = cfg.CookieMaxAge
+	}
+
+	sameSite := cookie.SameSite
+	if sameSite == "" {
+		sameSite = cfg.CookieSameSite
+	}
+	switch strings.ToLower(sameSite) {
+	case "strict":
+		hc.SameSite = http.SameSiteStrictMode
+	case "none":
+		// Browsers reject SameSite=None without Secure.
+		hc.SameSite = http.SameSiteNoneMode
+		hc.Secure = true
+	case "lax":
+		hc.SameSite = http.SameSiteLaxMode
+	}
+	if hc.Partitioned {
+		hc.Secure = true
+	}
+
+	http.SetCookie(c.res, hc)
+}
+
+// ClearCookie instructs the client to delete the cookie name.
+// The path must match the one the cookie was set with; it defaults to "/".
+func (c *Context) ClearCookie(name string, path ...string) {
+	cookie := &Cookie{Name: name, MaxAge: -1, Expires: time.Unix(0, 0)}
+	if len(path) > 0 {
+		cookie.Path = path[0]
+	}
+	c.SetCookie(cookie)
+}
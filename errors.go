@@ -0,0 +1,88 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Error is an error carrying an HTTP status code.
+// Handlers and framework helpers return it to produce a specific error
+// response through the ErrorHandler.
+type Error struct {
+	// Code is the HTTP status code of the response.
+	Code int
+
+	// Message is sent to the client.
+	Message string
+
+	// Err is the underlying cause, if any. It is not sent to the client.
+	Err error
+}
+
+// NewError creates an Error with the given status code.
+// The message defaults to the status text of code.
+func NewError(code int, message ...string) *Error {
+	e := &Error{Code: code, Message: http.StatusText(code)}
+	if len(message) > 0 {
+		e.Message = message[0]
+	}
+	return e
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrapError creates an Error with the given status code and cause.
+func wrapError(code int, err error) *Error {
+	return &Error{Code: code, Message: http.StatusText(code), Err: err}
+}
+
+// Errors is a list of errors. Middleware use it through Context.AddWarning
+// to attach non-fatal problems, such as deprecation notices or quota
+// warnings, that do not fail the request. errors.Is and errors.As look
+// into every element.
+type Errors []error
+
+// Error implements the error interface, joining the messages with "; ".
+func (e Errors) Error() string {
+	return strings.Join(e.Messages(), "; ")
+}
+
+// Unwrap returns the errors for errors.Is and errors.As.
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Messages returns the message of each error.
+func (e Errors) Messages() []string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return messages
+}
+
+// AddWarning attaches a non-fatal error to the request. Warnings do not
+// change the response by themselves; the ErrorHandler and middleware read
+// them with Warnings, and with Config.ResponseEnvelope enabled they are
+// listed in the envelope.
+func (c *Context) AddWarning(err error) {
+	if err != nil {
+		c.warnings = append(c.warnings, err)
+	}
+}
+
+// Warnings returns the warnings attached to the request, or nil.
+func (c *Context) Warnings() Errors {
+	return c.warnings
+}
@@ -0,0 +1,263 @@
+package mux
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Feed MIME types.
+const (
+	MIMEApplicationRSS  = "application/rss+xml; charset=utf-8"
+	MIMEApplicationAtom = "application/atom+xml; charset=utf-8"
+)
+
+// FeedFormat selects the syndication format rendered by Context.Feed.
+type FeedFormat int
+
+// Supported feed formats.
+const (
+	FeedRSS FeedFormat = iota
+	FeedAtom
+)
+
+// FeedData is a format independent description of a feed.
+type FeedData struct {
+	// ID uniquely identifies the feed. Atom requires it; it defaults to Link.
+	ID string
+
+	Title       string
+	Link        string
+	Description string
+	Author      string
+
+	// Updated is the last modification time of the feed.
+	// It defaults to the most recent item update.
+	Updated time.Time
+
+	Items []FeedItem
+}
+
+// FeedItem is a single entry of a feed.
+type FeedItem struct {
+	// ID uniquely identifies the item. It defaults to Link.
+	ID string
+
+	Title       string
+	Link        string
+	Description string
+	Author      string
+
+	// Content is the full content of the item, sent as escaped HTML.
+	Content string
+
+	Published time.Time
+	Updated   time.Time
+}
+
+// Feed renders feed as RSS 2.0 or Atom 1.0 with a 200 status and the
+// matching content type. All text is XML escaped.
+func (c *Context) Feed(format FeedFormat, feed FeedData) error {
+	var doc any
+	var contentType string
+	switch format {
+	case FeedRSS:
+		doc, contentType = newRSS(feed), MIMEApplicationRSS
+	case FeedAtom:
+		doc, contentType = newAtom(feed), MIMEApplicationAtom
+	default:
+		return fmt.Errorf("mux: unknown feed format %d", format)
+	}
+
+	buf := feedBuffers.acquire()
+	defer feedBuffers.release(buf)
+
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(buf).Encode(doc); err != nil {
+		return err
+	}
+
+	c.res.Header().Set(HeaderContentType, withCharset(contentType, CharsetUTF8))
+	c.res.WriteHeader(http.StatusOK)
+	_, err := c.res.Write(buf.Bytes())
+	return err
+}
+
+// rssFeed is the XML layout of an RSS 2.0 document.
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title,omitempty"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	GUID        *rssGUID `xml:"guid,omitempty"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// newRSS converts feed to its RSS 2.0 layout.
+func newRSS(feed FeedData) *rssFeed {
+	doc := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.Link,
+			Description:   feed.Description,
+			LastBuildDate: formatRSSTime(feedUpdated(feed)),
+		},
+	}
+
+	for _, item := range feed.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			Author:      item.Author,
+			PubDate:     formatRSSTime(item.Published),
+		}
+		if ri.Description == "" {
+			ri.Description = item.Content
+		}
+		if item.ID != "" {
+			ri.GUID = &rssGUID{Value: item.ID}
+		} else if item.Link != "" {
+			ri.GUID = &rssGUID{IsPermaLink: true, Value: item.Link}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+	return doc
+}
+
+// atomFeed is the XML layout of an Atom 1.0 document.
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Link     *atomLink   `xml:"link,omitempty"`
+	Author   *atomAuthor `xml:"author,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published,omitempty"`
+	Link      *atomLink    `xml:"link,omitempty"`
+	Author    *atomAuthor  `xml:"author,omitempty"`
+	Summary   string       `xml:"summary,omitempty"`
+	Content   *atomContent `xml:"content,omitempty"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// newAtom converts feed to its Atom 1.0 layout.
+func newAtom(feed FeedData) *atomFeed {
+	doc := &atomFeed{
+		ID:       firstNonEmpty(feed.ID, feed.Link),
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feedUpdated(feed).UTC().Format(time.RFC3339),
+	}
+	if feed.Link != "" {
+		doc.Link = &atomLink{Href: feed.Link, Rel: "alternate"}
+	}
+	if feed.Author != "" {
+		doc.Author = &atomAuthor{Name: feed.Author}
+	}
+
+	for _, item := range feed.Items {
+		updated := item.Updated
+		if updated.IsZero() {
+			updated = item.Published
+		}
+		entry := atomEntry{
+			ID:      firstNonEmpty(item.ID, item.Link),
+			Title:   item.Title,
+			Updated: updated.UTC().Format(time.RFC3339),
+			Summary: item.Description,
+		}
+		if !item.Published.IsZero() {
+			entry.Published = item.Published.UTC().Format(time.RFC3339)
+		}
+		if item.Link != "" {
+			entry.Link = &atomLink{Href: item.Link, Rel: "alternate"}
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		if item.Content != "" {
+			entry.Content = &atomContent{Type: "html", Value: item.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return doc
+}
+
+// feedUpdated returns feed.Updated, or the most recent item time if unset.
+func feedUpdated(feed FeedData) time.Time {
+	if !feed.Updated.IsZero() {
+		return feed.Updated
+	}
+
+	var latest time.Time
+	for _, item := range feed.Items {
+		for _, t := range []time.Time{item.Updated, item.Published} {
+			if t.After(latest) {
+				latest = t
+			}
+		}
+	}
+	return latest
+}
+
+// formatRSSTime formats t as RFC 1123 with a numeric zone, as RSS expects.
+// A zero time yields "".
+func formatRSSTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+// firstNonEmpty returns the first non-empty string of values.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,200 @@
+// Package flows issues and verifies signed, single-use tokens for email
+// verification, magic link login and similar flows.
+package flows
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/obadmatar/mux"
+)
+
+// Token verification errors.
+var (
+	ErrInvalidToken = errors.New("flows: invalid token")
+	ErrExpiredToken = errors.New("flows: token expired")
+	ErrTokenUsed    = errors.New("flows: token already used")
+)
+
+// Store tracks consumed tokens so each token can be used once.
+type Store interface {
+	// Consume marks the token id as used until expires.
+	// It reports false if the token was already consumed.
+	Consume(ctx context.Context, id string, expires time.Time) (bool, error)
+}
+
+// Issuer issues and verifies tokens signed with an HMAC-SHA256 secret.
+type Issuer struct {
+	secret []byte
+	store  Store
+
+	// clock, if set, overrides the Clock of the request context.
+	clock mux.Clock
+}
+
+// New creates an Issuer. The secret should be at least 32 random bytes.
+// If store is nil, consumed tokens are tracked in memory, which only suits
+// single instance deployments.
+func New(secret []byte, store Store) *Issuer {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Issuer{secret: secret, store: store}
+}
+
+// SetClock makes the Issuer tell the time with clock, e.g. a fake one in
+// tests. Otherwise Issue uses mux.SystemClock and Verify the Clock of its
+// context, which is Config.Clock for request contexts. It must be called
+// before the Issuer is used.
+func (i *Issuer) SetClock(clock mux.Clock) {
+	i.clock = clock
+}
+
+// clockFrom returns the Clock of the Issuer, or the one of ctx.
+func (i *Issuer) clockFrom(ctx context.Context) mux.Clock {
+	if i.clock != nil {
+		return i.clock
+	}
+	return mux.ClockFrom(ctx)
+}
+
+// claims is the signed payload of a token.
+type claims struct {
+	ID      string `json:"i"`
+	Purpose string `json:"p"`
+	Subject string `json:"s"`
+	Expires int64  `json:"e"`
+}
+
+// Issue returns a token for subject, e.g. a user ID or email address, valid
+// for the given purpose until ttl elapses. The token is URL safe.
+func (i *Issuer) Issue(purpose, subject string, ttl time.Duration) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims{
+		ID:      base64.RawURLEncoding.EncodeToString(id),
+		Purpose: purpose,
+		Subject: subject,
+		Expires: i.clockFrom(context.Background()).Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(i.sign(encoded)), nil
+}
+
+// Verify checks token for purpose, consumes it and returns its subject.
+func (i *Issuer) Verify(ctx context.Context, token, purpose string) (string, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, i.sign(encoded)) {
+		return "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil || c.Purpose != purpose {
+		return "", ErrInvalidToken
+	}
+
+	clock := i.clockFrom(ctx)
+	expires := time.Unix(c.Expires, 0)
+	if clock.Now().After(expires) {
+		return "", ErrExpiredToken
+	}
+
+	// The store prunes expired tokens with the same Clock.
+	if i.clock != nil {
+		ctx = mux.WithClock(ctx, i.clock)
+	}
+
+	fresh, err := i.store.Consume(ctx, c.ID, expires)
+	if err != nil {
+		return "", err
+	}
+	if !fresh {
+		return "", ErrTokenUsed
+	}
+	return c.Subject, nil
+}
+
+// Handler returns a mux.Handler verifying the token found in the query
+// parameter param for purpose, then calling fn with its subject.
+// Invalid tokens produce a 400 *mux.Error, expired or used ones a 410.
+func (i *Issuer) Handler(purpose, param string, fn func(c *mux.Context, subject string) error) mux.Handler {
+	return mux.HandlerFunc(func(c *mux.Context) error {
+		r := c.Request()
+		subject, err := i.Verify(r.Context(), r.URL.Query().Get(param), purpose)
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			return &mux.Error{Code: http.StatusBadRequest, Message: "invalid token", Err: err}
+		case errors.Is(err, ErrExpiredToken), errors.Is(err, ErrTokenUsed):
+			return &mux.Error{Code: http.StatusGone, Message: "token expired or already used", Err: err}
+		case err != nil:
+			return err
+		}
+		return fn(c, subject)
+	})
+}
+
+// sign returns the HMAC of the encoded payload.
+func (i *Issuer) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mutex     sync.Mutex
+	used      map[string]time.Time
+	lastPrune time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{used: make(map[string]time.Time)}
+}
+
+// Consume implements Store. Expired records are pruned at most once a
+// minute, as told by the Clock of ctx.
+func (s *MemoryStore) Consume(ctx context.Context, id string, expires time.Time) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := mux.ClockFrom(ctx).Now()
+	if now.Sub(s.lastPrune) > time.Minute {
+		for key, exp := range s.used {
+			if now.After(exp) {
+				delete(s.used, key)
+			}
+		}
+		s.lastPrune = now
+	}
+
+	if _, used := s.used[id]; used {
+		return false, nil
+	}
+	s.used[id] = expires
+	return true, nil
+}
@@ -0,0 +1,85 @@
+package mux
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+)
+
+// FormValue returns the first value of the form field name in the request
+// body, URL-encoded or multipart. It returns "" if the field is missing or
+// the body is not a valid form; use BindForm to get the error.
+func (c *Context) FormValue(name string) string {
+	values, err := c.postForm()
+	if err != nil {
+		return ""
+	}
+	return values.Get(name)
+}
+
+// MultipartForm parses the multipart form body, keeping up to
+// Config.MultipartMemory of its parts in memory and the rest in temporary
+// files removed when the request ends. A body that is not a multipart form
+// produces a 400 *Error, and one over Config.BodyLimit a 413 *Error.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	form, err := c.multipartForm()
+	if err != nil {
+		return nil, bodyError(err)
+	}
+	return form, nil
+}
+
+// FormFile returns the first file uploaded in the multipart form field
+// name. A missing file produces a 400 *Error wrapping http.ErrMissingFile.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	if files := form.File[name]; len(files) > 0 {
+		return files[0], nil
+	}
+	return nil, wrapError(http.StatusBadRequest, http.ErrMissingFile)
+}
+
+// SaveFile writes the uploaded file fh to path, replacing any existing
+// file. path is used as is; never derive it from fh.Filename, which the
+// client chose.
+func (c *Context) SaveFile(fh *multipart.FileHeader, path string) (err error) {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, dst.Close())
+	}()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// multipartForm parses the multipart form body once, within
+// Config.MultipartMemory, and removes its temporary files when the request
+// ends.
+func (c *Context) multipartForm() (*multipart.Form, error) {
+	if c.req.MultipartForm != nil {
+		return c.req.MultipartForm, nil
+	}
+	if err := c.req.ParseMultipartForm(c.app.config.MultipartMemory); err != nil {
+		return nil, err
+	}
+
+	form := c.req.MultipartForm
+	c.onRelease(func() {
+		form.RemoveAll()
+	})
+	return form, nil
+}
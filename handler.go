@@ -1,8 +1,10 @@
 package mux
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 )
 
 // Handler defines an interface for handling HTTP requests.
@@ -30,8 +32,10 @@ type MiddlewareFunc func(Handler) Handler
 type ErrorHandler = func(*Context, error) error
 
 // DefaultErrorHandler is the fallback error handler used if none is provided in Config.
-// It sends a 500 Internal Server Error with a generic message to the client,
-// and logs the detailed error for server-side visibility.
+// An *Error is answered with its status code and message. Any other error
+// sends a 500 Internal Server Error with a generic message to the client,
+// and logs the detailed error for server-side visibility. Errors raised
+// after the response was written are only logged.
 var DefaultErrorHandler ErrorHandler = func(c *Context, err error) error {
 	// Defensive: nil Context or nil response writer should never happen, but avoid panic if so.
 	if c == nil || c.res == nil {
@@ -39,19 +43,54 @@ var DefaultErrorHandler ErrorHandler = func(c *Context, err error) error {
 		return err
 	}
 
+	// A response was already sent, e.g. a fallback error body; only log.
+	if c.writer.Written() {
+		log.Printf("error after response was written: %v", err)
+		return err
+	}
+
+	// Bodies read past Config.BodyLimit are answered with 413.
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		err = wrapError(http.StatusRequestEntityTooLarge, err)
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		// Server-side failures are still logged.
+		if e.Code >= http.StatusInternalServerError {
+			log.Printf("server error: %v", err)
+		}
+		c.writeError(e.Code, e.Message)
+		return err
+	}
+
 	// Log the error. In production, this might go to a structured logger with request metadata.
 	log.Printf("internal server error: %v", err)
 
 	// Write generic 500 response. Avoid exposing internal error messages to the client.
-	http.Error(
-		c.res,
-		http.StatusText(http.StatusInternalServerError),
+	c.writeError(
 		http.StatusInternalServerError,
+		http.StatusText(http.StatusInternalServerError),
 	)
 
 	return err
 }
 
+// writeError writes an error response, as an Envelope when
+// Config.ResponseEnvelope is enabled and as plain text otherwise.
+func (c *Context) writeError(code int, message string) {
+	if c.app != nil && c.app.config.ResponseEnvelope {
+		c.writeJSON(code, Envelope{
+			Error:    &EnvelopeError{Code: code, Message: message},
+			Meta:     c.meta,
+			Warnings: c.warnings.Messages(),
+		})
+		return
+	}
+	http.Error(c.res, message, code)
+}
+
 // Context represents the Context which hold the HTTP request and response.
 // It has methods for the request query string, parameters, body, HTTP headers and so on.
 type Context struct {
@@ -63,4 +102,48 @@ type Context struct {
 
 	// res is the HTTP response writer.
 	res http.ResponseWriter
+
+	// writer wraps the original response writer to record status and size.
+	writer responseWriter
+
+	// status is the status code set with Status.
+	status int
+
+	// query caches the parsed query string.
+	query url.Values
+
+	// queryRaw is the raw query query was parsed from.
+	queryRaw string
+
+	// meta holds the response envelope meta values.
+	meta map[string]any
+
+	// locals holds request-scoped values set through Set.
+	locals map[string]any
+
+	// memo holds the values loaded through Memo.
+	memo map[string]any
+
+	// baggage caches the parsed W3C Baggage of the request.
+	baggage Baggage
+
+	// errorHandled is set once HandleError ran, so the dispatcher does not
+	// run the ErrorHandler a second time.
+	errorHandled bool
+
+	// warnings holds the non-fatal errors attached with AddWarning.
+	warnings Errors
+
+	// route is the route serving the request.
+	route *Route
+
+	// returnHooks run when the handler returns, before the ErrorHandler.
+	returnHooks []func()
+
+	// releaseHooks run when the Context is returned to the pool.
+	releaseHooks []func()
+
+	// adapterErr carries the handler error through net/http middleware
+	// wrapped by WrapMiddleware.
+	adapterErr error
 }
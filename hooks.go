@@ -0,0 +1,147 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Hooks is the registry of the lifecycle callbacks of an App, returned by
+// App.Hooks. Hooks run in registration order.
+type Hooks struct {
+	// mutex serializes registrations and protects the hooks not run per
+	// request.
+	mutex      sync.Mutex
+	onRoute    []func(RouteInfo) error
+	onListen   []func(addr string) error
+	onShutdown []func(ctx context.Context) error
+
+	// onRequest and onResponse are read by every request, so they are
+	// replaced as a whole rather than modified.
+	onRequest  atomic.Pointer[[]func(*Context) error]
+	onResponse atomic.Pointer[[]func(*Context)]
+}
+
+// Hooks returns the lifecycle hook registry of the app.
+func (app *App) Hooks() *Hooks {
+	return &app.hooks
+}
+
+// OnRoute registers a hook run as each route is registered, including
+// mounted apps, e.g. to audit or document routes. It sees the route as
+// passed to the registration method, before options such as Name are
+// applied. If the hook returns an error, registration panics; the route
+// is already in place by then.
+func (h *Hooks) OnRoute(hook func(RouteInfo) error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onRoute = append(h.onRoute, hook)
+}
+
+// OnRequest registers a hook run for every request, matched or not, before
+// the global middleware. An error skips the middleware and the handler and
+// goes to the ErrorHandler.
+func (h *Hooks) OnRequest(hook func(*Context) error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onRequest.Store(appendHook(h.onRequest.Load(), hook))
+}
+
+// OnResponse registers a hook run for every request once the handler and
+// the ErrorHandler are done, e.g. to record the response status.
+func (h *Hooks) OnResponse(hook func(*Context)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onResponse.Store(appendHook(h.onResponse.Load(), hook))
+}
+
+// OnListen registers a hook run by Listen once the listener is bound, with
+// its address, e.g. to warm up caches or announce the instance. An error
+// closes the listener and is returned by Listen.
+func (h *Hooks) OnListen(hook func(addr string) error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onListen = append(h.onListen, hook)
+}
+
+// OnShutdown registers a hook run by App.ShutdownWithContext once
+// connections are drained, to close resources such as database pools.
+// The context carries the shutdown deadline.
+func (h *Hooks) OnShutdown(hook func(ctx context.Context) error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onShutdown = append(h.onShutdown, hook)
+}
+
+// appendHook returns a copy of the hooks with hook appended.
+func appendHook[F any](hooks *[]F, hook F) *[]F {
+	var list []F
+	if hooks != nil {
+		list = *hooks
+	}
+	list = append(slices.Clip(list), hook)
+	return &list
+}
+
+// routeRegistered runs the OnRoute hooks for r. It panics if one fails.
+func (h *Hooks) routeRegistered(r *Route) {
+	h.mutex.Lock()
+	hooks := h.onRoute
+	h.mutex.Unlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	r.app.mutex.Lock()
+	info := r.info()
+	r.app.mutex.Unlock()
+	for _, hook := range hooks {
+		if err := hook(info); err != nil {
+			panic(fmt.Sprintf("mux: route %s %s: %v", info.Method, info.Path, err))
+		}
+	}
+}
+
+// request runs the OnRequest hooks, stopping at the first error.
+func (h *Hooks) request(c *Context) error {
+	if hooks := h.onRequest.Load(); hooks != nil {
+		for _, hook := range *hooks {
+			if err := hook(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// response runs the OnResponse hooks.
+func (h *Hooks) response(c *Context) {
+	if hooks := h.onResponse.Load(); hooks != nil {
+		for _, hook := range *hooks {
+			hook(c)
+		}
+	}
+}
+
+// listen runs the OnListen hooks, stopping at the first error.
+func (h *Hooks) listen(addr string) error {
+	h.mutex.Lock()
+	hooks := h.onListen
+	h.mutex.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shutdownHooks returns the OnShutdown hooks.
+func (h *Hooks) shutdownHooks() []func(ctx context.Context) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.onShutdown
+}
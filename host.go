@@ -0,0 +1,214 @@
+package mux
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Host patterns are matched label by label against the request host,
+// without its port:
+//
+//	api.example.com       a literal host
+//	{tenant}.example.com  a wildcard matching one non-empty label
+//
+// Literal hosts are tried before hosts with wildcards, then hosts are
+// tried in registration order. Requests whose host matches no pattern, or
+// whose path matches no route of the matching hosts, are routed with the
+// routes registered without a host.
+
+// hostRouter routes the requests for a host pattern.
+type hostRouter struct {
+	// pattern is the host pattern, lowercased.
+	pattern string
+
+	// labels are the parsed labels of pattern, from left to right.
+	labels []segment
+
+	// router matches the paths of the routes registered for the host.
+	router router
+}
+
+// parseHost splits the host pattern into labels.
+func parseHost(pattern string) ([]segment, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty host pattern")
+	}
+
+	var labels []segment
+	for label := range strings.SplitSeq(pattern, ".") {
+		if !strings.HasPrefix(label, "{") {
+			if label == "" || strings.ContainsAny(label, "{}:/") {
+				return nil, fmt.Errorf("bad label %q", label)
+			}
+			labels = append(labels, segment{kind: segmentLiteral, value: label})
+			continue
+		}
+
+		name, ok := strings.CutSuffix(label[1:], "}")
+		if !ok || !validWildcardName(name) {
+			return nil, fmt.Errorf("bad wildcard %q", label)
+		}
+		labels = append(labels, segment{kind: segmentWildcard, value: name})
+	}
+	return labels, nil
+}
+
+// match reports whether host matches the pattern of h, returning the
+// values of its wildcards.
+func (h *hostRouter) match(host string) ([]pathParam, bool) {
+	if strings.Count(host, ".") != len(h.labels)-1 {
+		return nil, false
+	}
+
+	var params []pathParam
+	i := 0
+	for label := range strings.SplitSeq(host, ".") {
+		switch seg := h.labels[i]; {
+		case seg.kind == segmentLiteral:
+			if label != seg.value {
+				return nil, false
+			}
+		case label == "":
+			return nil, false
+		default:
+			params = append(params, pathParam{name: seg.value, value: label})
+		}
+		i++
+	}
+	return params, true
+}
+
+// literal reports whether the pattern of h has no wildcard.
+func (h *hostRouter) literal() bool {
+	return !slices.ContainsFunc(h.labels, func(seg segment) bool {
+		return seg.kind != segmentLiteral
+	})
+}
+
+// requestHost returns the host of r without port and trailing dot,
+// lowercased for matching.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// Host creates a route group answering only requests for the host pattern,
+// e.g. "api.example.com" or "{tenant}.example.com". Wildcard labels are
+// exposed like path wildcards, through Context.Param. Routes registered
+// without a host still serve the requests no host route matches, so
+// several tenants or sites can share one App.
+// It panics if the pattern is malformed.
+func (app *App) Host(pattern string, middleware ...MiddlewareFunc) *Group {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	return &Group{
+		app:        app,
+		host:       app.hostRouter(pattern),
+		middleware: nameMiddleware(middleware),
+	}
+}
+
+// hostRouter returns the router of the host pattern, adding it if needed.
+// The caller must hold app.mutex.
+func (app *App) hostRouter(pattern string) *hostRouter {
+	pattern = strings.ToLower(pattern)
+
+	var hosts []*hostRouter
+	if current := app.hosts.Load(); current != nil {
+		hosts = *current
+	}
+	for _, h := range hosts {
+		if h.pattern == pattern {
+			return h
+		}
+	}
+
+	labels, err := parseHost(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("mux: host pattern %q: %v", pattern, err))
+	}
+	h := &hostRouter{pattern: pattern, labels: labels}
+	app.router.mutex.Lock()
+	h.router.constraints = maps.Clone(app.router.constraints)
+	app.router.mutex.Unlock()
+
+	// Keep literal hosts ahead of wildcard ones. Requests read the list
+	// without locking, so it is replaced rather than modified.
+	i := len(hosts)
+	if h.literal() {
+		i = slices.IndexFunc(hosts, func(h *hostRouter) bool { return !h.literal() })
+		if i < 0 {
+			i = len(hosts)
+		}
+	}
+	hosts = slices.Insert(slices.Clone(hosts), i, h)
+	app.hosts.Store(&hosts)
+	return h
+}
+
+// routers returns the router of every host pattern and the router of the
+// routes registered without a host.
+func (app *App) routers() []*router {
+	routers := []*router{&app.router}
+	if hosts := app.hosts.Load(); hosts != nil {
+		for _, h := range *hosts {
+			routers = append(routers, &h.router)
+		}
+	}
+	return routers
+}
+
+// lookup returns the route serving method for the escaped path on host:
+// the route of the first matching host pattern that has one, else the
+// route registered without a host.
+func (app *App) lookup(host, method, escapedPath string) (routeMatch, bool) {
+	if hosts := app.hosts.Load(); hosts != nil {
+		for _, h := range *hosts {
+			if m, ok := h.lookup(host, method, escapedPath); ok {
+				return m, true
+			}
+		}
+	}
+	return app.router.lookup(method, escapedPath)
+}
+
+// lookup returns the route of h serving method for the escaped path, if
+// host matches the pattern of h.
+func (h *hostRouter) lookup(host, method, escapedPath string) (routeMatch, bool) {
+	params, ok := h.match(host)
+	if !ok {
+		return routeMatch{}, false
+	}
+	m, ok := h.router.lookup(method, escapedPath)
+	if !ok {
+		return routeMatch{}, false
+	}
+	m.params = append(params, m.params...)
+	// Patterns read "[METHOD ]host/path", as with http.ServeMux.
+	i := strings.IndexByte(m.pattern, '/')
+	m.pattern = m.pattern[:i] + h.pattern + m.pattern[i:]
+	return m, true
+}
+
+// allowed returns the methods with a route matching the escaped path on
+// host, for the Allow header.
+func (app *App) allowed(host, escapedPath string) []string {
+	methods := make(map[string]bool)
+	if hosts := app.hosts.Load(); hosts != nil {
+		for _, h := range *hosts {
+			if _, ok := h.match(host); ok {
+				h.router.methods(escapedPath, methods)
+			}
+		}
+	}
+	app.router.methods(escapedPath, methods)
+	return allowHeader(methods)
+}
@@ -0,0 +1,43 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HTMX request and response headers.
+const (
+	HeaderHXRequest  = "HX-Request"
+	HeaderHXRedirect = "HX-Redirect"
+	HeaderHXTrigger  = "HX-Trigger"
+)
+
+// IsHTMX reports whether the request was issued by htmx.
+func (c *Context) IsHTMX() bool {
+	return c.req.Header.Get(HeaderHXRequest) == "true"
+}
+
+// HXRedirect makes htmx perform a full client-side redirect to url.
+// It writes an empty 200 response, as htmx ignores the header on 3xx responses.
+func (c *Context) HXRedirect(url string) error {
+	c.res.Header().Set(HeaderHXRedirect, url)
+	c.res.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// HXTrigger sets the HX-Trigger response header so htmx raises client-side events.
+// A string is sent as is, e.g. "saved" or "saved, closeModal"; any other
+// value, typically map[string]any with event details, is sent as JSON.
+func (c *Context) HXTrigger(events any) error {
+	if s, ok := events.(string); ok {
+		c.res.Header().Set(HeaderHXTrigger, s)
+		return nil
+	}
+
+	b, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	c.res.Header().Set(HeaderHXTrigger, string(b))
+	return nil
+}
@@ -0,0 +1,109 @@
+package mux
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// Forwarding headers set by reverse proxies.
+const (
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+// parseTrustedProxies parses Config.TrustedProxies. Entries are CIDR
+// ranges or single addresses.
+func parseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("mux: invalid trusted proxy %q", entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// trustedProxy reports whether addr is in Config.TrustedProxies.
+func (app *App) trustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range app.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerAddr returns the address of the connection peer.
+func (c *Context) peerAddr() (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(c.req.RemoteAddr)
+	if err != nil {
+		host = c.req.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	return addr.Unmap(), err == nil
+}
+
+// IP returns the client address. When the peer is a trusted proxy, it is
+// the rightmost X-Forwarded-For address that is not a trusted proxy, or
+// X-Real-IP without X-Forwarded-For. Otherwise it is the peer address, so
+// clients cannot spoof it with forged headers.
+func (c *Context) IP() string {
+	peer, ok := c.peerAddr()
+	if !ok {
+		return c.req.RemoteAddr
+	}
+	if !c.app.trustedProxy(peer) {
+		return peer.String()
+	}
+
+	forwarded := c.forwardedFor()
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		if !c.app.trustedProxy(forwarded[i]) || i == 0 {
+			return forwarded[i].String()
+		}
+	}
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(c.req.Header.Get(HeaderXRealIP))); err == nil {
+		return realIP.Unmap().String()
+	}
+	return peer.String()
+}
+
+// IPs returns the X-Forwarded-For addresses, client first, when the peer
+// is a trusted proxy, and nil otherwise. Entries that are not addresses
+// are skipped.
+func (c *Context) IPs() []string {
+	peer, ok := c.peerAddr()
+	if !ok || !c.app.trustedProxy(peer) {
+		return nil
+	}
+
+	forwarded := c.forwardedFor()
+	ips := make([]string, len(forwarded))
+	for i, addr := range forwarded {
+		ips[i] = addr.String()
+	}
+	return ips
+}
+
+// forwardedFor parses the X-Forwarded-For headers of the request.
+func (c *Context) forwardedFor() []netip.Addr {
+	var addrs []netip.Addr
+	for _, value := range c.req.Header.Values(HeaderXForwardedFor) {
+		for entry := range strings.SplitSeq(value, ",") {
+			if addr, err := netip.ParseAddr(strings.TrimSpace(entry)); err == nil {
+				addrs = append(addrs, addr.Unmap())
+			}
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrJSONTooDeep is the error of JSON bodies nested deeper than
+// JSONOptions.MaxDepth. It surfaces as the Err of a 400 *Error.
+var ErrJSONTooDeep = errors.New("mux: JSON body exceeds the maximum nesting depth")
+
+// JSONOptions configures how BindJSON, and Bind for JSON bodies, decode
+// request bodies.
+type JSONOptions struct {
+	// DisallowUnknownFields rejects objects with keys that match no field
+	// of the destination struct.
+	//
+	// Default: false
+	DisallowUnknownFields bool `json:"disallow_unknown_fields"`
+
+	// MaxDepth is the maximum nesting of objects and arrays. Deeper bodies
+	// are rejected while they are read, before they are decoded.
+	// Zero means no limit.
+	//
+	// Default: 0
+	MaxDepth int `json:"max_depth"`
+
+	// UseNumber decodes numbers into interface values as json.Number
+	// instead of float64, keeping large integers exact.
+	//
+	// Default: false
+	UseNumber bool `json:"use_number"`
+}
+
+// JSONOptions overrides Config.JSONDecoding for the route, e.g. to accept
+// unknown fields on a legacy endpoint of a strict API.
+func (r *Route) JSONOptions(options JSONOptions) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	r.jsonOptions = &options
+	return r
+}
+
+// jsonOptions returns the JSON decoding options of the request.
+func (c *Context) jsonOptions() JSONOptions {
+	if c.route != nil && c.route.jsonOptions != nil {
+		return *c.route.jsonOptions
+	}
+	return c.app.config.JSONDecoding
+}
+
+// newJSONDecoder returns a decoder of r configured with options.
+func newJSONDecoder(r io.Reader, options JSONOptions) *json.Decoder {
+	if options.MaxDepth > 0 {
+		r = &depthReader{r: r, max: options.MaxDepth}
+	}
+	dec := json.NewDecoder(r)
+	if options.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if options.UseNumber {
+		dec.UseNumber()
+	}
+	return dec
+}
+
+// depthReader fails with ErrJSONTooDeep once the JSON read through it
+// nests deeper than max.
+type depthReader struct {
+	r     io.Reader
+	max   int
+	depth int
+
+	// inString and escaped track string literals, whose brackets do not count.
+	inString bool
+	escaped  bool
+}
+
+// Read implements io.Reader.
+func (d *depthReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	for _, b := range p[:n] {
+		switch {
+		case d.escaped:
+			d.escaped = false
+		case d.inString:
+			switch b {
+			case '\\':
+				d.escaped = true
+			case '"':
+				d.inString = false
+			}
+		case b == '"':
+			d.inString = true
+		case b == '{' || b == '[':
+			d.depth++
+			if d.depth > d.max {
+				return 0, ErrJSONTooDeep
+			}
+		case b == '}' || b == ']':
+			d.depth--
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,85 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+)
+
+// JSONStreamWriter writes a JSON array to the response one item at a time.
+// It is created by Context.JSONStream and must be closed to terminate the array.
+type JSONStreamWriter struct {
+	c       *Context
+	started bool
+	closed  bool
+}
+
+// JSONStream returns a writer emitting a JSON array incrementally, for large
+// result sets that should not be held in memory. The 200 response header is
+// sent with the first item.
+func (c *Context) JSONStream() *JSONStreamWriter {
+	return &JSONStreamWriter{c: c}
+}
+
+// WriteItem encodes v and appends it to the array, flushing it to the client.
+// Each item is encoded fully before anything is written, so an encoding
+// error never leaves a partial item behind.
+func (s *JSONStreamWriter) WriteItem(v any) error {
+	if s.closed {
+		return errors.New("mux: write to closed JSON stream")
+	}
+
+	buf := jsonBuffers.acquire()
+	defer jsonBuffers.release(buf)
+
+	if err := encodeJSON(buf, v); err != nil {
+		return err
+	}
+
+	sep := ","
+	if !s.started {
+		s.start()
+		sep = "["
+	}
+	if _, err := s.c.res.Write([]byte(sep)); err != nil {
+		return err
+	}
+	// Drop the newline added by the encoder.
+	if _, err := s.c.res.Write(buf.Bytes()[:buf.Len()-1]); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// Close terminates the array. Closing a stream without items writes "[]".
+func (s *JSONStreamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	end := "]"
+	if !s.started {
+		s.start()
+		end = "[]"
+	}
+	if _, err := s.c.res.Write([]byte(end)); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// start sends the response header.
+func (s *JSONStreamWriter) start() {
+	s.started = true
+	s.c.res.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	s.c.res.WriteHeader(http.StatusOK)
+}
+
+// flush pushes buffered output to the client, ignoring writers that cannot flush.
+func (s *JSONStreamWriter) flush() error {
+	err := http.NewResponseController(s.c.res).Flush()
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
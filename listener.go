@@ -0,0 +1,97 @@
+package mux
+
+import (
+	"net"
+	"sync"
+)
+
+// ipLimitListener wraps a net.Listener and closes connections from remote
+// addresses that already hold the maximum number of open connections.
+// Rejecting at accept time is much cheaper than limiting per request.
+type ipLimitListener struct {
+	net.Listener
+
+	// max is the number of open connections allowed per remote IP.
+	max int
+
+	// mutex protects conns.
+	mutex sync.Mutex
+
+	// conns counts open connections per remote IP.
+	conns map[string]int
+}
+
+// newIPLimitListener returns ln limited to max open connections per remote IP.
+func newIPLimitListener(ln net.Listener, max int) *ipLimitListener {
+	return &ipLimitListener{
+		Listener: ln,
+		max:      max,
+		conns:    make(map[string]int),
+	}
+}
+
+// Accept waits for the next connection whose remote IP is below the limit.
+// Excess connections are closed immediately.
+func (l *ipLimitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip := remoteIP(conn.RemoteAddr())
+		if !l.acquire(ip) {
+			conn.Close()
+			continue
+		}
+		return &ipLimitConn{Conn: conn, listener: l, ip: ip}, nil
+	}
+}
+
+// acquire reserves a connection slot for ip, reporting false if none is left.
+func (l *ipLimitListener) acquire(ip string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.conns[ip] >= l.max {
+		return false
+	}
+	l.conns[ip]++
+	return true
+}
+
+// release frees a connection slot held by ip.
+func (l *ipLimitListener) release(ip string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.conns[ip] <= 1 {
+		delete(l.conns, ip)
+		return
+	}
+	l.conns[ip]--
+}
+
+// ipLimitConn releases its slot in the ipLimitListener when closed.
+type ipLimitConn struct {
+	net.Conn
+	listener *ipLimitListener
+	ip       string
+	once     sync.Once
+}
+
+// Close closes the connection and releases its slot exactly once.
+func (c *ipLimitConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { c.listener.release(c.ip) })
+	return err
+}
+
+// remoteIP returns the IP part of addr, or addr itself if it has no port.
+func remoteIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
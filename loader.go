@@ -0,0 +1,183 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// HandlerFactory builds a Handler from the options of a declarative route.
+type HandlerFactory func(options map[string]any) (Handler, error)
+
+// RouteSpec describes a single route in a declarative route definition.
+// Exactly one of Handler, Static and Proxy is set.
+type RouteSpec struct {
+	// Method is the HTTP method of the route. It is required for Handler
+	// routes, must be empty or GET for Static routes, and empty means
+	// every method for Proxy routes.
+	Method string `json:"method,omitempty"`
+
+	// Path is the path pattern of the route. Static routes serve the files
+	// below it.
+	Path string `json:"path"`
+
+	// Handler is the name of a factory registered with RegisterHandler.
+	Handler string `json:"handler,omitempty"`
+
+	// Options is passed to the handler factory as is.
+	Options map[string]any `json:"options,omitempty"`
+
+	// Static is the directory served below Path, as with App.Static.
+	Static string `json:"static,omitempty"`
+
+	// Proxy is the base URL of an upstream the requests are passed to,
+	// path and query included, through httputil.ReverseProxy.
+	Proxy string `json:"proxy,omitempty"`
+
+	// Middleware lists middleware registered with RegisterMiddleware,
+	// applied to the route in order.
+	Middleware []string `json:"middleware,omitempty"`
+}
+
+// RegisterHandler registers a named handler factory that declarative route
+// definitions loaded with LoadRoutes can refer to.
+func (app *App) RegisterHandler(name string, factory HandlerFactory) {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	if app.handlers == nil {
+		app.handlers = make(map[string]HandlerFactory)
+	}
+	app.handlers[name] = factory
+}
+
+// LoadRoutes registers the routes described by spec, a JSON array of RouteSpec.
+// Handlers are resolved against the factories registered with RegisterHandler
+// and middleware against the registry of RegisterMiddleware.
+// Every route is resolved and its pattern checked before any is registered,
+// so an invalid spec, e.g. a malformed or conflicting pattern, returns an
+// error and leaves the application unchanged.
+func (app *App) LoadRoutes(spec []byte) error {
+	var specs []RouteSpec
+	dec := json.NewDecoder(bytes.NewReader(spec))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&specs); err != nil {
+		return fmt.Errorf("mux: invalid route spec: %w", err)
+	}
+
+	routes := make([]*Route, len(specs))
+	for i, rs := range specs {
+		route, err := app.loadRoute(rs)
+		if err != nil {
+			return fmt.Errorf("mux: route %d: %w", i, err)
+		}
+		routes[i] = route
+	}
+
+	if err := app.insertRoutes(routes); err != nil {
+		return err
+	}
+	for _, route := range routes {
+		app.hooks.routeRegistered(route)
+	}
+	return nil
+}
+
+// loadRoute resolves rs into a route that is not registered yet.
+func (app *App) loadRoute(rs RouteSpec) (*Route, error) {
+	if !strings.HasPrefix(rs.Path, "/") {
+		return nil, fmt.Errorf("absolute path is required")
+	}
+	method := strings.ToUpper(rs.Method)
+	for m := range strings.SplitSeq(method, ",") {
+		if m != "" && !validMethod(m) {
+			return nil, fmt.Errorf("invalid method %q", rs.Method)
+		}
+	}
+
+	var targets int
+	for _, target := range []string{rs.Handler, rs.Static, rs.Proxy} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return nil, fmt.Errorf("exactly one of handler, static and proxy is required")
+	}
+
+	path := rs.Path
+	var h Handler
+	switch {
+	case rs.Static != "":
+		if method != "" && method != http.MethodGet {
+			return nil, fmt.Errorf("static routes answer GET only")
+		}
+		method, path = http.MethodGet, staticPattern(rs.Path)
+		h = newStaticHandler(rs.Static)
+
+	case rs.Proxy != "":
+		target, err := url.Parse(rs.Proxy)
+		if err != nil || target.Scheme == "" || target.Host == "" {
+			return nil, fmt.Errorf("invalid proxy target %q", rs.Proxy)
+		}
+		h = WrapHandler(httputil.NewSingleHostReverseProxy(target))
+
+	default:
+		if method == "" {
+			return nil, fmt.Errorf("method is required")
+		}
+		app.mutex.Lock()
+		factory, ok := app.handlers[rs.Handler]
+		app.mutex.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown handler %q", rs.Handler)
+		}
+
+		var err error
+		if h, err = factory(rs.Options); err != nil {
+			return nil, fmt.Errorf("handler %q: %w", rs.Handler, err)
+		}
+	}
+
+	middleware, err := app.lookupMiddleware(rs.Middleware)
+	if err != nil {
+		return nil, err
+	}
+	return app.newRoute(nil, method, path, h, middleware), nil
+}
+
+// insertRoutes registers routes all or none: every pattern is checked
+// against the registered ones and the others before any is added.
+func (app *App) insertRoutes(routes []*Route) error {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	pending := make(map[string]bool)
+	for i, route := range routes {
+		for method := range strings.SplitSeq(route.method, ",") {
+			if err := app.router.check(method, route.path, pending); err != nil {
+				return fmt.Errorf("mux: route %d: %w", i, err)
+			}
+		}
+	}
+	for _, route := range routes {
+		app.register(route, route.path)
+		app.routes = append(app.routes, route)
+	}
+	return nil
+}
+
+// validMethod reports whether m is a valid HTTP method token.
+func validMethod(m string) bool {
+	for i := 0; i < len(m); i++ {
+		c := m[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`()<>@,;:\"/[]?={}`, c) >= 0 {
+			return false
+		}
+	}
+	return m != ""
+}
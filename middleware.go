@@ -0,0 +1,77 @@
+package mux
+
+import "fmt"
+
+// namedMiddleware is a middleware with its display name, shown by
+// Context.HandlerChain and Config.ChainHeader.
+type namedMiddleware struct {
+	name string
+	fn   MiddlewareFunc
+}
+
+// nameMiddleware names middleware by reflection.
+func nameMiddleware(middleware []MiddlewareFunc) []namedMiddleware {
+	named := make([]namedMiddleware, len(middleware))
+	for i, m := range middleware {
+		named[i] = namedMiddleware{name: middlewareName(m), fn: m}
+	}
+	return named
+}
+
+// RegisterMiddleware registers mw under name, so groups, declarative route
+// definitions and App.UseNamed can refer to it by name. The name is also
+// shown in the handler chain debug output.
+// It panics if the name is already registered.
+func (app *App) RegisterMiddleware(name string, mw MiddlewareFunc) {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	if _, exists := app.namedMiddleware[name]; exists {
+		panic(fmt.Sprintf("mux: middleware %q is already registered", name))
+	}
+	if app.namedMiddleware == nil {
+		app.namedMiddleware = make(map[string]MiddlewareFunc)
+	}
+	app.namedMiddleware[name] = mw
+}
+
+// UseNamed adds middleware registered with RegisterMiddleware to the
+// application, like Use. It panics if a name is not registered.
+func (app *App) UseNamed(names ...string) {
+	named := app.mustLookupMiddleware(names)
+
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+	app.pushMiddleware(named)
+}
+
+// UseNamed adds middleware registered with RegisterMiddleware to the group,
+// like Use. It panics if a name is not registered.
+func (g *Group) UseNamed(names ...string) {
+	g.middleware = append(g.middleware, g.app.mustLookupMiddleware(names)...)
+}
+
+// lookupMiddleware resolves names against the middleware registry.
+func (app *App) lookupMiddleware(names []string) ([]namedMiddleware, error) {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	named := make([]namedMiddleware, len(names))
+	for i, name := range names {
+		mw, ok := app.namedMiddleware[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q", name)
+		}
+		named[i] = namedMiddleware{name: name, fn: mw}
+	}
+	return named, nil
+}
+
+// mustLookupMiddleware is lookupMiddleware panicking on unknown names.
+func (app *App) mustLookupMiddleware(names []string) []namedMiddleware {
+	named, err := app.lookupMiddleware(names)
+	if err != nil {
+		panic("mux: " + err.Error())
+	}
+	return named
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/obadmatar/mux"
+)
+
+// ChaosConfig defines the config for Chaos.
+// Rates are probabilities between 0 and 1 applied independently per request.
+type ChaosConfig struct {
+	// EnvVar names the environment variable that enables fault injection.
+	// Chaos does nothing unless the variable is set to a true value
+	// ("1", "true", ...) when the middleware is created.
+	//
+	// Default: "MUX_CHAOS"
+	EnvVar string
+
+	// Next defines a function to skip this middleware when it returns true,
+	// e.g. to target specific routes only.
+	//
+	// Default: nil
+	Next func(c *mux.Context) bool
+
+	// LatencyRate is the probability of delaying the request by Latency.
+	//
+	// Default: 0
+	LatencyRate float64
+
+	// Latency is the injected delay.
+	//
+	// Default: 0
+	Latency time.Duration
+
+	// LatencyJitter adds a random delay of up to LatencyJitter to Latency.
+	//
+	// Default: 0
+	LatencyJitter time.Duration
+
+	// ErrorRate is the probability of answering with ErrorCode instead of
+	// calling the handler.
+	//
+	// Default: 0
+	ErrorRate float64
+
+	// ErrorCode is the status of injected errors.
+	//
+	// Default: 503
+	ErrorCode int
+
+	// DropRate is the probability of closing the connection without a response.
+	//
+	// Default: 0
+	DropRate float64
+}
+
+// Chaos injects latency, errors and dropped connections into requests for
+// resilience testing of clients and their retry policies. It is inert
+// unless the environment variable named by EnvVar is set to a true value,
+// so it can stay registered in production builds.
+// Injected errors are *mux.Error values handled by the ErrorHandler.
+func Chaos(config ChaosConfig) mux.MiddlewareFunc {
+	if config.EnvVar == "" {
+		config.EnvVar = "MUX_CHAOS"
+	}
+	if config.ErrorCode == 0 {
+		config.ErrorCode = http.StatusServiceUnavailable
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv(config.EnvVar))
+
+	return func(next mux.Handler) mux.Handler {
+		if !enabled {
+			return next
+		}
+
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			if config.Next != nil && config.Next(c) {
+				return next.Handle(c)
+			}
+
+			if chance(config.DropRate) {
+				dropConnection(c)
+			}
+
+			if chance(config.LatencyRate) {
+				delay := config.Latency
+				if config.LatencyJitter > 0 {
+					delay += rand.N(config.LatencyJitter)
+				}
+				ctx := c.Request().Context()
+				select {
+				case <-mux.ClockFrom(ctx).After(delay):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			if chance(config.ErrorRate) {
+				return mux.NewError(config.ErrorCode, "chaos: injected fault")
+			}
+			return next.Handle(c)
+		})
+	}
+}
+
+// chance reports true with probability rate.
+func chance(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// dropConnection closes the client connection without a response. It does
+// not return.
+func dropConnection(c *mux.Context) {
+	if conn, _, err := http.NewResponseController(c.Response()).Hijack(); err == nil {
+		conn.Close()
+	}
+	// Aborts the handler; net/http resets HTTP/2 streams and does not log it.
+	panic(http.ErrAbortHandler)
+}
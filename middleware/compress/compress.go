@@ -0,0 +1,394 @@
+// Package compress provides a middleware compressing responses according
+// to the Accept-Encoding request header.
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"maps"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/obadmatar/mux"
+)
+
+// Writer is a compressing writer that can be reused through Reset, as
+// implemented by gzip.Writer, flate.Writer and the common brotli and zstd
+// packages.
+type Writer interface {
+	io.WriteCloser
+
+	// Flush writes any pending compressed data.
+	Flush() error
+
+	// Reset discards the state of the writer and makes it write to w.
+	Reset(w io.Writer)
+}
+
+// Config defines the config for the compress middleware.
+type Config struct {
+	// Level is the compression level of gzip and deflate, from
+	// gzip.BestSpeed to gzip.BestCompression.
+	//
+	// Default: gzip.DefaultCompression
+	Level int
+
+	// MinLength is the response size in bytes below which responses are
+	// sent uncompressed.
+	//
+	// Default: 1024
+	MinLength int
+
+	// Encoders adds content codings, e.g. "br" or "zstd", backed by any
+	// library. Each function returns a new Writer with the level of choice;
+	// writers are pooled and Reset for every response.
+	//
+	// Default: nil
+	Encoders map[string]func() Writer
+
+	// Preference orders the content codings when the client accepts
+	// several with the same quality.
+	//
+	// Default: "zstd", "br", "gzip", "deflate"
+	Preference []string
+
+	// SkipTypes lists content types that are never compressed because they
+	// are already compressed or streamed. Entries ending in "/" match a
+	// whole top-level type.
+	//
+	// Default: image/, video/, audio/, font/woff2, application/zip,
+	// application/gzip, application/zstd, application/x-7z-compressed,
+	// application/pdf, text/event-stream
+	SkipTypes []string
+
+	// Next defines a function to skip this middleware when it returns true.
+	//
+	// Default: nil
+	Next func(c *mux.Context) bool
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	Level:      gzip.DefaultCompression,
+	MinLength:  1024,
+	Preference: []string{"zstd", "br", "gzip", "deflate"},
+	SkipTypes: []string{
+		"image/", "video/", "audio/", "font/woff2",
+		"application/zip", "application/gzip", "application/zstd",
+		"application/x-7z-compressed", "application/pdf", "text/event-stream",
+	},
+}
+
+// New creates a compress middleware. gzip and deflate are built in; other
+// codings are added through Config.Encoders. Responses are buffered until
+// MinLength bytes are written, so small responses go out uncompressed.
+// SVG images are compressed despite the image/ default skip entry.
+func New(config ...Config) mux.MiddlewareFunc {
+	cfg := configDefault(config...)
+
+	factories := map[string]func() Writer{
+		"gzip": func() Writer {
+			w, _ := gzip.NewWriterLevel(io.Discard, cfg.Level)
+			return w
+		},
+		"deflate": func() Writer {
+			w, _ := flate.NewWriter(io.Discard, cfg.Level)
+			return w
+		},
+	}
+	for name, factory := range cfg.Encoders {
+		factories[strings.ToLower(name)] = factory
+	}
+
+	// Codings in order of preference, with a writer pool each.
+	var offers []string
+	for _, name := range cfg.Preference {
+		if _, ok := factories[name]; ok && !slices.Contains(offers, name) {
+			offers = append(offers, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(factories)) {
+		if !slices.Contains(offers, name) {
+			offers = append(offers, name)
+		}
+	}
+	pools := make(map[string]*sync.Pool, len(factories))
+	for name, factory := range factories {
+		pools[name] = &sync.Pool{New: func() any { return factory() }}
+	}
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			if cfg.Next != nil && cfg.Next(c) {
+				return next.Handle(c)
+			}
+
+			res := c.Response()
+			res.Header().Add("Vary", "Accept-Encoding")
+			coding := negotiate(c.Request().Header.Get("Accept-Encoding"), offers)
+			if coding == "" {
+				return next.Handle(c)
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: res,
+				cfg:            &cfg,
+				coding:         coding,
+				pool:           pools[coding],
+			}
+			c.SetResponse(cw)
+			err := next.Handle(c)
+			c.SetResponse(res)
+
+			if cerr := cw.close(); cerr != nil && err == nil {
+				err = cerr
+			}
+			return err
+		})
+	}
+}
+
+// negotiate returns the accepted coding among offers with the highest
+// quality, ties broken by the order of offers, or "" for identity.
+func negotiate(accept string, offers []string) string {
+	if accept == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for part := range strings.SplitSeq(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		qualities[strings.ToLower(strings.TrimSpace(coding))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, ok := qualities[offer]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of the response and compresses it once
+// it reaches MinLength, if its content type allows.
+type compressWriter struct {
+	http.ResponseWriter
+
+	cfg    *Config
+	coding string
+	pool   *sync.Pool
+
+	// status is the status code passed to WriteHeader, sent with the decision.
+	status int
+
+	// buf holds the response, up to MinLength bytes, until the decision is
+	// made. It comes from the mux buffer pool.
+	buf *bytes.Buffer
+
+	// decided is set once the response is committed, compressed or not.
+	decided bool
+
+	// encoder is the compressing writer, nil for an uncompressed response.
+	encoder Writer
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if w.buf == nil {
+			w.buf = mux.AcquireBuffer(min(len(b), w.cfg.MinLength))
+		}
+		// Only the bytes up to MinLength are held; the rest of a large
+		// write goes out once the decision is made.
+		n := min(len(b), w.cfg.MinLength-w.buf.Len())
+		w.buf.Write(b[:n])
+		if w.buf.Len() < w.cfg.MinLength {
+			return n, nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		if n == len(b) {
+			return n, nil
+		}
+		m, err := w.write(b[n:])
+		return n + m, err
+	}
+	return w.write(b)
+}
+
+// write writes b to the committed response.
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide commits the response, compressed if allowed and compressible,
+// and writes the buffered start of the body.
+func (w *compressWriter) decide(allowed bool) error {
+	w.decided = true
+	h := w.Header()
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if h.Get(mux.HeaderContentType) == "" && w.buf != nil {
+		h.Set(mux.HeaderContentType, http.DetectContentType(w.buf.Bytes()))
+	}
+
+	if allowed && w.compressible() {
+		h.Set("Content-Encoding", w.coding)
+		h.Del("Content-Length")
+		w.encoder = w.pool.Get().(Writer)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if w.buf == nil {
+		return nil
+	}
+	defer func() {
+		mux.ReleaseBuffer(w.buf)
+		w.buf = nil
+	}()
+	if w.encoder != nil {
+		_, err := w.encoder.Write(w.buf.Bytes())
+		return err
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	return err
+}
+
+// compressible reports whether the response may be compressed.
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	if w.status < 200 || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(h.Get(mux.HeaderContentType))
+	if err != nil {
+		return false
+	}
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+	for _, skip := range w.cfg.SkipTypes {
+		if mediaType == skip || strings.HasSuffix(skip, "/") && strings.HasPrefix(mediaType, skip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Flush commits the response and flushes the compressed data, so streamed
+// responses keep flowing.
+func (w *compressWriter) Flush() {
+	w.FlushError()
+}
+
+// FlushError is Flush returning the error, used by http.ResponseController.
+func (w *compressWriter) FlushError() error {
+	if !w.decided {
+		if err := w.decide(w.buf != nil && w.buf.Len() >= w.cfg.MinLength); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection over; nothing is compressed afterwards.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close commits a response still held in the buffer, uncompressed since it
+// is below MinLength, and finishes the compressed stream.
+func (w *compressWriter) close() error {
+	if !w.decided {
+		if w.buf == nil && w.status == 0 {
+			// Nothing was written, let the error handler respond.
+			return nil
+		}
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.encoder == nil {
+		return nil
+	}
+
+	err := w.encoder.Close()
+	w.encoder.Reset(io.Discard)
+	w.pool.Put(w.encoder)
+	w.encoder = nil
+	return err
+}
+
+// configDefault returns the first config with unset fields filled from ConfigDefault.
+func configDefault(config ...Config) Config {
+	if len(config) == 0 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.Level == 0 {
+		cfg.Level = ConfigDefault.Level
+	}
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = ConfigDefault.MinLength
+	}
+	if cfg.Preference == nil {
+		cfg.Preference = ConfigDefault.Preference
+	}
+	if cfg.SkipTypes == nil {
+		cfg.SkipTypes = ConfigDefault.SkipTypes
+	}
+	return cfg
+}
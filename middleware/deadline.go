@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/obadmatar/mux"
+)
+
+// DeadlineConfig defines the config for Deadline.
+type DeadlineConfig struct {
+	// Header is the request header carrying the remaining time budget of the
+	// caller, in the grpc-timeout format ("250m" for 250ms, "2S") or as a
+	// Go duration ("1.5s").
+	//
+	// Default: "X-Request-Timeout"
+	Header string
+
+	// Trusted reports whether the caller may set the deadline.
+	//
+	// Default: callers from loopback and private network addresses
+	Trusted func(c *mux.Context) bool
+
+	// Max caps the accepted budget. Zero means no cap.
+	//
+	// Default: 0
+	Max time.Duration
+}
+
+// Deadline propagates deadlines across service hops: the request context of
+// a trusted caller is given the deadline announced in Header, so downstream
+// calls made with it fail fast once the caller has given up. The deadline
+// can only shrink; headers that do not parse are ignored.
+func Deadline(config DeadlineConfig) mux.MiddlewareFunc {
+	if config.Header == "" {
+		config.Header = "X-Request-Timeout"
+	}
+	if config.Trusted == nil {
+		config.Trusted = privateCaller
+	}
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			value := c.Request().Header.Get(config.Header)
+			if value == "" || !config.Trusted(c) {
+				return next.Handle(c)
+			}
+			budget, ok := parseTimeout(value)
+			if !ok {
+				return next.Handle(c)
+			}
+			if config.Max > 0 {
+				budget = min(budget, config.Max)
+			}
+
+			req := c.Request()
+			ctx, cancel := context.WithTimeout(req.Context(), budget)
+			defer cancel()
+			c.SetRequest(req.WithContext(ctx))
+			return next.Handle(c)
+		})
+	}
+}
+
+// grpcTimeoutUnits maps the grpc-timeout unit suffixes to durations.
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// parseTimeout parses a grpc-timeout value or a Go duration. The grpc
+// format wins where both apply, so "250m" means 250 milliseconds.
+func parseTimeout(value string) (time.Duration, bool) {
+	// grpc-timeout: up to 8 digits followed by a unit.
+	if n := len(value); n >= 2 && n <= 9 {
+		if unit, ok := grpcTimeoutUnits[value[n-1]]; ok {
+			if v, err := strconv.ParseUint(value[:n-1], 10, 32); err == nil {
+				return time.Duration(v) * unit, v > 0
+			}
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	return d, err == nil && d > 0
+}
+
+// privateCaller reports whether the request comes from a loopback or
+// private network address.
+func privateCaller(c *mux.Context) bool {
+	ip := net.ParseIP(clientIP(c.Request()))
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
+}
+
+// clientIP returns the IP part of the request's remote address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
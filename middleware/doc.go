@@ -0,0 +1,3 @@
+// Package middleware provides small, dependency-free middleware for mux
+// applications. Larger middleware lives in the sub-packages.
+package middleware
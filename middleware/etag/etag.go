@@ -0,0 +1,279 @@
+// Package etag provides a middleware adding ETags to responses and
+// answering conditional requests with 304 Not Modified.
+package etag
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/obadmatar/mux"
+)
+
+// Config defines the config for the etag middleware.
+type Config struct {
+	// Weak generates weak ETags (W/"..."), which only promise semantic
+	// equivalence, e.g. when a compressing middleware runs further out.
+	//
+	// Default: false
+	Weak bool
+
+	// MaxSize is the largest response body buffered to compute an ETag, in
+	// bytes. Bigger responses are streamed as is, without an ETag.
+	//
+	// Default: 1 * 1024 * 1024
+	MaxSize int
+
+	// Next defines a function to skip this middleware when it returns true.
+	//
+	// Default: nil
+	Next func(c *mux.Context) bool
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	Weak:    false,
+	MaxSize: 1 * 1024 * 1024,
+}
+
+// New creates an etag middleware. Successful GET and HEAD responses up to
+// MaxSize are buffered and get an ETag computed from the body. Responses
+// the handler set an ETag on are not buffered. Requests whose If-None-Match
+// matches get a 304 without a body. Bigger and flushed responses are
+// streamed as is, without an ETag, as are HEAD responses without a body.
+func New(config ...Config) mux.MiddlewareFunc {
+	cfg := configDefault(config...)
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			req := c.Request()
+			if cfg.Next != nil && cfg.Next(c) || req.Method != http.MethodGet && req.Method != http.MethodHead {
+				return next.Handle(c)
+			}
+
+			res := c.Response()
+			bw := &bufferWriter{
+				ResponseWriter: res,
+				maxSize:        cfg.MaxSize,
+				ifNoneMatch:    req.Header.Get("If-None-Match"),
+			}
+			defer bw.release()
+
+			c.SetResponse(bw)
+			err := next.Handle(c)
+			c.SetResponse(res)
+
+			if bw.streaming {
+				return err
+			}
+			if !bw.wrote {
+				// Nothing was written, let the error handler respond.
+				return err
+			}
+
+			status := bw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			// HEAD responses usually carry no body, whose hash would not
+			// match the ETag of the GET response.
+			if status == http.StatusOK && (req.Method != http.MethodHead || len(bw.body()) > 0) {
+				tag := generate(bw.body(), cfg.Weak)
+				res.Header().Set("ETag", tag)
+				if match(bw.ifNoneMatch, tag) {
+					notModified(res)
+					return err
+				}
+			}
+
+			res.WriteHeader(status)
+			if _, werr := res.Write(bw.body()); werr != nil && err == nil {
+				err = werr
+			}
+			return err
+		})
+	}
+}
+
+// generate returns the ETag of body.
+func generate(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// match reports whether the If-None-Match value matches tag, using the
+// weak comparison RFC 9110 prescribes for If-None-Match.
+func match(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified answers the request with a 304 without a body.
+func notModified(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del(mux.HeaderContentType)
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// bufferWriter holds the response until the chain returns, unless the
+// handler tags or flushes it, or it grows over maxSize.
+type bufferWriter struct {
+	http.ResponseWriter
+
+	maxSize     int
+	ifNoneMatch string
+
+	// buf holds the body, from the mux buffer pool once written to.
+	buf    *bytes.Buffer
+	status int
+
+	// wrote is set once a status or body was written.
+	wrote bool
+
+	// streaming is set once the response is passed through.
+	streaming bool
+
+	// discard is set once a 304 was sent for the ETag of the handler.
+	discard bool
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.discard {
+		return
+	}
+	if w.streaming || code < 200 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+	if !w.wrote {
+		w.begin()
+	}
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if w.discard {
+		return len(b), nil
+	}
+	if !w.wrote {
+		w.begin()
+	}
+	if !w.streaming && len(w.body())+len(b) > w.maxSize {
+		if err := w.stream(); err != nil {
+			return 0, err
+		}
+	}
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.buf == nil {
+		w.buf = mux.AcquireBuffer(len(b))
+	}
+	return w.buf.Write(b)
+}
+
+// begin runs on the first write of a status or body. A response the
+// handler set an ETag on is not buffered: it gets a 304 if If-None-Match
+// matches, or is streamed.
+func (w *bufferWriter) begin() {
+	w.wrote = true
+	tag := w.Header().Get("ETag")
+	if tag == "" {
+		return
+	}
+	if (w.status == 0 || w.status == http.StatusOK) && match(w.ifNoneMatch, tag) {
+		notModified(w.ResponseWriter)
+		w.streaming, w.discard = true, true
+		return
+	}
+	w.stream()
+}
+
+// stream switches to passing the response through, sending what was
+// buffered so far.
+func (w *bufferWriter) stream() error {
+	w.streaming = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if body := w.body(); len(body) > 0 {
+		_, err := w.ResponseWriter.Write(body)
+		return err
+	}
+	return nil
+}
+
+// body returns the buffered body.
+func (w *bufferWriter) body() []byte {
+	if w.buf == nil {
+		return nil
+	}
+	return w.buf.Bytes()
+}
+
+// release returns the buffer to the pool.
+func (w *bufferWriter) release() {
+	if w.buf != nil {
+		mux.ReleaseBuffer(w.buf)
+		w.buf = nil
+	}
+}
+
+// Flush switches to streaming: the buffered response is sent as is.
+func (w *bufferWriter) Flush() {
+	w.FlushError()
+}
+
+// FlushError is Flush returning the error, used by http.ResponseController.
+func (w *bufferWriter) FlushError() error {
+	if !w.streaming {
+		if err := w.stream(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection over.
+func (w *bufferWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.streaming = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *bufferWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// configDefault returns the first config with unset fields filled from ConfigDefault.
+func configDefault(config ...Config) Config {
+	if len(config) == 0 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = ConfigDefault.MaxSize
+	}
+	return cfg
+}
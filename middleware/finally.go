@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"runtime/debug"
+
+	"github.com/obadmatar/mux"
+)
+
+// Finally runs fn after the rest of the chain, however it ends: with a
+// response, an error, or a panic. err is the error returned by the chain,
+// or a *mux.PanicError for a panic, which is re-raised once fn returns so
+// recovery middleware still sees it. Register Finally before middleware
+// that may short-circuit, so cleanup such as metrics or transaction
+// rollback always runs.
+func Finally(fn func(c *mux.Context, err error)) mux.MiddlewareFunc {
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					fn(c, &mux.PanicError{Value: r, Stack: debug.Stack()})
+					panic(r)
+				}
+				fn(c, err)
+			}()
+			return next.Handle(c)
+		})
+	}
+}
@@ -0,0 +1,410 @@
+// Package jwt provides a middleware authenticating requests with JSON Web
+// Tokens (RFC 7519) signed with HMAC, RSA or ECDSA keys.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	_ "crypto/sha256" // registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for crypto.Hash
+
+	"github.com/obadmatar/mux"
+)
+
+// Token verification errors, wrapped in the 401 *mux.Error returned by the
+// middleware.
+var (
+	ErrMissingToken = errors.New("jwt: missing token")
+	ErrMalformed    = errors.New("jwt: malformed token")
+	ErrAlgorithm    = errors.New("jwt: unexpected signing algorithm")
+	ErrSignature    = errors.New("jwt: invalid signature")
+	ErrExpired      = errors.New("jwt: token expired")
+	ErrNotValidYet  = errors.New("jwt: token not valid yet")
+	ErrAudience     = errors.New("jwt: invalid audience")
+	ErrIssuer       = errors.New("jwt: invalid issuer")
+	ErrKeyType      = errors.New("jwt: unsupported key type")
+)
+
+// Claims are the claims of a verified token.
+type Claims map[string]any
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// String returns the claim name as a string, or "" if it is not one.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Header is the JOSE header of a token.
+type Header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// Config defines the config for the jwt middleware.
+type Config struct {
+	// Key verifies signatures: a []byte secret for HS256, HS384 and HS512,
+	// an *rsa.PublicKey for RS256, RS384, RS512, PS256, PS384 and PS512,
+	// or an *ecdsa.PublicKey on P-256 for ES256, P-384 for ES384 and P-521
+	// for ES512.
+	//
+	// Required unless KeyFunc is set.
+	Key any
+
+	// KeyFunc returns the key for a token, e.g. looked up by its key ID
+	// for key rotation. It takes precedence over Key.
+	//
+	// Default: nil
+	KeyFunc func(h Header) (any, error)
+
+	// Algorithms lists the accepted "alg" values. Tokens signed with any
+	// other algorithm are rejected, preventing algorithm confusion.
+	//
+	// Default: the algorithms of the type of Key, or of its curve
+	Algorithms []string
+
+	// Lookup lists where to find the token, in order, as "header:<name>",
+	// "cookie:<name>" or "query:<name>". Header values may carry a
+	// "Bearer " prefix.
+	//
+	// Default: []string{"header:Authorization"}
+	Lookup []string
+
+	// Audience, if set, must be listed in the "aud" claim.
+	//
+	// Default: ""
+	Audience string
+
+	// Issuer, if set, must equal the "iss" claim.
+	//
+	// Default: ""
+	Issuer string
+
+	// Leeway is the clock skew tolerated when checking "exp" and "nbf".
+	//
+	// Default: 0
+	Leeway time.Duration
+
+	// ContextKey is the Context.Locals key the Claims are stored under.
+	//
+	// Default: "jwt"
+	ContextKey string
+
+	// Next defines a function to skip this middleware when it returns true.
+	//
+	// Default: nil
+	Next func(c *mux.Context) bool
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	Lookup:     []string{"header:Authorization"},
+	ContextKey: "jwt",
+}
+
+// New creates a jwt middleware. Requests without a valid token get a 401
+// *mux.Error wrapping the verification error, with a WWW-Authenticate
+// header. The claims of valid tokens are stored in Context.Locals under
+// ContextKey; see ClaimsFrom. Expiry is checked with the application Clock.
+func New(config Config) mux.MiddlewareFunc {
+	cfg := configDefault(config)
+	if cfg.Key == nil && cfg.KeyFunc == nil {
+		panic("jwt: Key or KeyFunc is required")
+	}
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			if cfg.Next != nil && cfg.Next(c) {
+				return next.Handle(c)
+			}
+
+			claims, err := cfg.verify(c, lookup(c, cfg.Lookup))
+			if err != nil {
+				c.Response().Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				return &mux.Error{Code: http.StatusUnauthorized, Message: http.StatusText(http.StatusUnauthorized), Err: err}
+			}
+			c.Locals(cfg.ContextKey, claims)
+			return next.Handle(c)
+		})
+	}
+}
+
+// ClaimsFrom returns the claims stored by the middleware under the default
+// ContextKey, or nil.
+func ClaimsFrom(c *mux.Context) Claims {
+	claims, _ := c.Locals(ConfigDefault.ContextKey).(Claims)
+	return claims
+}
+
+// lookup returns the first token found in the request.
+func lookup(c *mux.Context, sources []string) string {
+	r := c.Request()
+	for _, source := range sources {
+		kind, name, _ := strings.Cut(source, ":")
+		var token string
+		switch kind {
+		case "header":
+			token = r.Header.Get(name)
+			if scheme, rest, ok := strings.Cut(token, " "); ok && strings.EqualFold(scheme, "Bearer") {
+				token = strings.TrimSpace(rest)
+			}
+		case "cookie":
+			if cookie, err := r.Cookie(name); err == nil {
+				token = cookie.Value
+			}
+		case "query":
+			token = c.Query(name)
+		}
+		if token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// verify checks the token and returns its claims.
+func (cfg *Config) verify(c *mux.Context, token string) (Claims, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var header Header
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformed
+	}
+
+	key := cfg.Key
+	if cfg.KeyFunc != nil {
+		var err error
+		if key, err = cfg.KeyFunc(header); err != nil {
+			return nil, err
+		}
+	}
+	allowed := cfg.Algorithms
+	if allowed == nil {
+		allowed = algorithmsFor(key)
+	}
+	if !slices.Contains(allowed, header.Algorithm) {
+		return nil, ErrAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if err := verifySignature(header.Algorithm, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if err := cfg.validate(claims, mux.ClockFrom(c.Request().Context()).Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// validate checks the registered claims.
+func (cfg *Config) validate(claims Claims, now time.Time) error {
+	exp, ok, err := numericDate(claims, "exp")
+	if err != nil {
+		return err
+	}
+	if ok && now.After(exp.Add(cfg.Leeway)) {
+		return ErrExpired
+	}
+	nbf, ok, err := numericDate(claims, "nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(cfg.Leeway).Before(nbf) {
+		return ErrNotValidYet
+	}
+	if cfg.Issuer != "" && claims.String("iss") != cfg.Issuer {
+		return ErrIssuer
+	}
+	if cfg.Audience != "" {
+		switch aud := claims["aud"].(type) {
+		case string:
+			if aud != cfg.Audience {
+				return ErrAudience
+			}
+		case []any:
+			if !slices.Contains(aud, any(cfg.Audience)) {
+				return ErrAudience
+			}
+		default:
+			return ErrAudience
+		}
+	}
+	return nil
+}
+
+// numericDate returns the NumericDate claim name, if present. A claim that
+// is not a number makes the token malformed.
+func numericDate(claims Claims, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	seconds, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %q is not a number", ErrMalformed, name)
+	}
+	return unixTime(seconds), true, nil
+}
+
+// unixTime converts a NumericDate to a time.
+func unixTime(seconds float64) time.Time {
+	whole := math.Floor(seconds)
+	return time.Unix(int64(whole), int64((seconds-whole)*float64(time.Second)))
+}
+
+// decodeSegment decodes a base64url JSON segment into v.
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// hashes maps the algorithm suffixes to their hash functions.
+var hashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+// curves maps the ECDSA algorithms to the names of their curves.
+var curves = map[string]string{
+	"ES256": "P-256",
+	"ES384": "P-384",
+	"ES512": "P-521",
+}
+
+// algorithmsFor returns the algorithms usable with key.
+func algorithmsFor(key any) []string {
+	switch k := key.(type) {
+	case []byte, string:
+		return []string{"HS256", "HS384", "HS512"}
+	case *rsa.PublicKey:
+		return []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case *ecdsa.PublicKey:
+		for alg, curve := range curves {
+			if k.Curve.Params().Name == curve {
+				return []string{alg}
+			}
+		}
+	}
+	return nil
+}
+
+// verifySignature checks signature over signed with alg and key.
+func verifySignature(alg string, key any, signed string, signature []byte) error {
+	if len(alg) != 5 {
+		return ErrAlgorithm
+	}
+	hash, ok := hashes[alg[2:]]
+	if !ok {
+		return ErrAlgorithm
+	}
+
+	switch alg[:2] {
+	case "HS":
+		var secret []byte
+		switch k := key.(type) {
+		case []byte:
+			secret = k
+		case string:
+			secret = []byte(k)
+		default:
+			return ErrKeyType
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrSignature
+		}
+		return nil
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrKeyType
+		}
+		var err error
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(k, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(k, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return ErrSignature
+		}
+		return nil
+	case "ES":
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrKeyType
+		}
+		// Each algorithm is bound to one curve.
+		if k.Curve.Params().Name != curves[alg] {
+			return fmt.Errorf("%w: %s with a %s key", ErrAlgorithm, alg, k.Curve.Params().Name)
+		}
+		// The signature is r and s as fixed-size big-endian integers.
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return ErrSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrAlgorithm, alg)
+}
+
+// configDefault returns config with unset fields filled from ConfigDefault.
+func configDefault(cfg Config) Config {
+	if cfg.Lookup == nil {
+		cfg.Lookup = ConfigDefault.Lookup
+	}
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = ConfigDefault.ContextKey
+	}
+	return cfg
+}
@@ -0,0 +1,201 @@
+// Package logger provides an access-log middleware.
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/obadmatar/mux"
+)
+
+// Supported log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config defines the config for the logger middleware.
+type Config struct {
+	// Format is FormatText, FormatJSON, or a text/template executed with an Entry,
+	// e.g. "{{.Status}} {{.Method}} {{.Path}}\n".
+	//
+	// Default: FormatText
+	Format string
+
+	// Output is the writer log lines are written to.
+	//
+	// Default: os.Stdout
+	Output io.Writer
+
+	// RequestIDHeader is the request header holding the request ID.
+	//
+	// Default: "X-Request-ID"
+	RequestIDHeader string
+
+	// Fields lists Context value keys, set by handlers through Context.Set,
+	// that are added to each log line when present.
+	//
+	// Default: nil
+	Fields []string
+
+	// Next defines a function to skip this middleware when it returns true.
+	//
+	// Default: nil
+	Next func(c *mux.Context) bool
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	Format:          FormatText,
+	Output:          os.Stdout,
+	RequestIDHeader: "X-Request-ID",
+}
+
+// Entry holds the data of a single access-log line.
+type Entry struct {
+	Time      time.Time      `json:"time"`
+	Status    int            `json:"status"`
+	Method    string         `json:"method"`
+	Path      string         `json:"path"`
+	Latency   time.Duration  `json:"latency"`
+	Bytes     int64          `json:"bytes"`
+	RequestID string         `json:"request_id,omitempty"`
+	IP        string         `json:"ip"`
+	Error     string         `json:"error,omitempty"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// bufferPool reuses the buffers log lines are formatted into.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// New creates a logger middleware.
+// Errors returned by the chain are handed to the ErrorHandler before the
+// line is written, so the logged status is the one sent to the client.
+func New(config ...Config) mux.MiddlewareFunc {
+	cfg := configDefault(config...)
+
+	// Writes to Output are serialized so lines never interleave.
+	var mutex sync.Mutex
+	format, err := newFormatter(cfg.Format)
+	if err != nil {
+		panic(fmt.Sprintf("logger: %v", err))
+	}
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			if cfg.Next != nil && cfg.Next(c) {
+				return next.Handle(c)
+			}
+
+			start := time.Now()
+			chainErr := next.Handle(c)
+			c.HandleError(chainErr)
+
+			req := c.Request()
+			entry := Entry{
+				Time:      start,
+				Status:    c.ResponseStatus(),
+				Method:    req.Method,
+				Path:      req.URL.Path,
+				Latency:   time.Since(start),
+				Bytes:     c.ResponseSize(),
+				RequestID: req.Header.Get(cfg.RequestIDHeader),
+				IP:        c.IP(),
+			}
+			if chainErr != nil {
+				entry.Error = chainErr.Error()
+			}
+			for _, key := range cfg.Fields {
+				if v := c.Get(key); v != nil {
+					if entry.Fields == nil {
+						entry.Fields = make(map[string]any, len(cfg.Fields))
+					}
+					entry.Fields[key] = v
+				}
+			}
+
+			buf := bufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer bufferPool.Put(buf)
+
+			if err := format(buf, &entry); err != nil {
+				return err
+			}
+
+			mutex.Lock()
+			_, err := cfg.Output.Write(buf.Bytes())
+			mutex.Unlock()
+			if err != nil && chainErr == nil {
+				return err
+			}
+			return chainErr
+		})
+	}
+}
+
+// formatter writes an Entry to buf.
+type formatter func(buf *bytes.Buffer, e *Entry) error
+
+// newFormatter returns the formatter for format.
+func newFormatter(format string) (formatter, error) {
+	switch format {
+	case FormatText:
+		return formatText, nil
+	case FormatJSON:
+		return func(buf *bytes.Buffer, e *Entry) error {
+			return json.NewEncoder(buf).Encode(e)
+		}, nil
+	}
+
+	tmpl, err := template.New("logger").Parse(format)
+	if err != nil {
+		return nil, err
+	}
+	return func(buf *bytes.Buffer, e *Entry) error {
+		return tmpl.Execute(buf, e)
+	}, nil
+}
+
+// formatText writes e as a single human readable line.
+func formatText(buf *bytes.Buffer, e *Entry) error {
+	fmt.Fprintf(buf, "%s | %3d | %13v | %-7s %s | %d bytes",
+		e.Time.Format(time.RFC3339), e.Status, e.Latency, e.Method, e.Path, e.Bytes)
+	if e.RequestID != "" {
+		fmt.Fprintf(buf, " | id=%s", e.RequestID)
+	}
+	for key, value := range e.Fields {
+		fmt.Fprintf(buf, " %s=%v", key, value)
+	}
+	if e.Error != "" {
+		fmt.Fprintf(buf, " | error=%q", e.Error)
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// configDefault returns the first config with unset fields filled from ConfigDefault.
+func configDefault(config ...Config) Config {
+	if len(config) == 0 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.Format == "" {
+		cfg.Format = ConfigDefault.Format
+	}
+	if cfg.Output == nil {
+		cfg.Output = ConfigDefault.Output
+	}
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = ConfigDefault.RequestIDHeader
+	}
+	return cfg
+}
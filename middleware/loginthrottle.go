@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/obadmatar/mux"
+)
+
+// LoginThrottleConfig defines the config for LoginThrottle.
+type LoginThrottleConfig struct {
+	// Identifier extracts the account identifier, e.g. the submitted user name,
+	// from the request. Attempts are tracked per identifier and client IP.
+	//
+	// Required.
+	Identifier func(c *mux.Context) string
+
+	// MaxAttempts is the number of consecutive failures allowed before the
+	// identifier and IP pair is locked out.
+	//
+	// Default: 5
+	MaxAttempts int
+
+	// BaseDelay is the first lockout duration. Each further failure doubles it.
+	//
+	// Default: 1s
+	BaseDelay time.Duration
+
+	// MaxDelay caps the lockout duration.
+	//
+	// Default: 15m
+	MaxDelay time.Duration
+
+	// Failed reports whether the login attempt failed, given the error
+	// returned by the handler. Attempts that neither fail nor succeed with
+	// a 2xx or 3xx response and no error, e.g. a 400 for a missing
+	// password, leave the record as is.
+	//
+	// Default: a 401 or 403 response or *mux.Error
+	Failed func(c *mux.Context, err error) bool
+
+	// Captcha is an optional hook consulted for locked out requests. If it
+	// reports a solved CAPTCHA, the attempt is let through despite the lockout.
+	//
+	// Default: nil
+	Captcha func(c *mux.Context) bool
+
+	// MaxKeys caps the number of identifier and IP pairs tracked. When it
+	// is reached, idle records are dropped, then the least recently seen
+	// one, so requests with made-up identifiers cannot grow memory without
+	// bound.
+	//
+	// Default: 100000
+	MaxKeys int
+}
+
+// loginOutcome is the result of a login attempt.
+type loginOutcome int
+
+const (
+	// loginNeutral attempts, rejected for other reasons than the
+	// credentials, neither count as failures nor clear the record.
+	loginNeutral loginOutcome = iota
+	loginFailure
+	loginSuccess
+)
+
+// loginAttempts tracks the failures of an identifier and IP pair.
+type loginAttempts struct {
+	failures    int
+	lockedUntil time.Time
+	lastSeen    time.Time
+
+	// pending counts the attempts still being handled. They count against
+	// MaxAttempts, so concurrent guesses cannot bypass the lockout.
+	pending int
+}
+
+// LoginThrottle protects authentication endpoints against credential
+// guessing. After MaxAttempts consecutive failures the identifier and IP pair
+// is locked out with exponential backoff; locked out attempts get a 429
+// *mux.Error and a Retry-After header. Attempts still in progress count as
+// failures until they complete, so parallel guesses are throttled too.
+// A successful login clears the record; other rejections, e.g. a 400 for
+// a malformed request, leave it as is.
+func LoginThrottle(config LoginThrottleConfig) mux.MiddlewareFunc {
+	if config.Identifier == nil {
+		panic("middleware: LoginThrottle requires an Identifier")
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = time.Second
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = 15 * time.Minute
+	}
+	if config.Failed == nil {
+		config.Failed = loginFailed
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = 100000
+	}
+
+	t := &loginTracker{config: config, attempts: make(map[string]*loginAttempts)}
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			key := config.Identifier(c) + "|" + c.IP()
+			now := mux.ClockFrom(c.Request().Context()).Now()
+
+			a, wait := t.reserve(key, now, false)
+			if wait > 0 {
+				if config.Captcha == nil || !config.Captcha(c) {
+					c.Response().Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+					return mux.NewError(http.StatusTooManyRequests)
+				}
+				a, _ = t.reserve(key, now, true)
+			}
+
+			// A panicking handler counts as a failed attempt.
+			outcome := loginFailure
+			defer func() { t.complete(a, key, now, outcome) }()
+
+			err := next.Handle(c)
+			switch {
+			case config.Failed(c, err):
+				outcome = loginFailure
+			case err == nil && c.ResponseStatus() < http.StatusBadRequest:
+				outcome = loginSuccess
+			default:
+				outcome = loginNeutral
+			}
+			return err
+		})
+	}
+}
+
+// loginTracker holds the login attempts of a LoginThrottle.
+type loginTracker struct {
+	config LoginThrottleConfig
+
+	mutex     sync.Mutex
+	attempts  map[string]*loginAttempts
+	lastSweep time.Time
+}
+
+// reserve counts an attempt for key as pending and returns its record. If
+// the pair is locked out, or its pending attempts already use up the
+// remaining ones, nothing is reserved and the wait is returned instead,
+// unless force is set.
+func (t *loginTracker) reserve(key string, now time.Time, force bool) (*loginAttempts, time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// Drop records idle for longer than the maximum lockout.
+	if now.Sub(t.lastSweep) > t.config.MaxDelay {
+		t.sweep(now)
+	}
+
+	a := t.attempts[key]
+	if a == nil {
+		if len(t.attempts) >= t.config.MaxKeys {
+			t.evict(now)
+		}
+		a = &loginAttempts{}
+		t.attempts[key] = a
+	}
+
+	if !force {
+		switch {
+		case now.Before(a.lockedUntil):
+			return nil, a.lockedUntil.Sub(now)
+		case a.failures+a.pending >= t.config.MaxAttempts:
+			return nil, t.config.BaseDelay
+		}
+	}
+	a.pending++
+	a.lastSeen = now
+	return a, 0
+}
+
+// complete records the outcome of an attempt reserved for key.
+func (t *loginTracker) complete(a *loginAttempts, key string, now time.Time, outcome loginOutcome) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	a.pending--
+	switch outcome {
+	case loginNeutral:
+		return
+	case loginSuccess:
+		a.failures = 0
+		a.lockedUntil = time.Time{}
+		if a.pending == 0 && t.attempts[key] == a {
+			delete(t.attempts, key)
+		}
+		return
+	}
+
+	a.failures++
+	a.lastSeen = now
+	if excess := a.failures - t.config.MaxAttempts; excess >= 0 {
+		delay := t.config.MaxDelay
+		if excess < 32 {
+			delay = min(t.config.BaseDelay<<excess, t.config.MaxDelay)
+		}
+		a.lockedUntil = now.Add(delay)
+	}
+}
+
+// sweep drops the records idle for longer than the maximum lockout.
+// The caller must hold t.mutex.
+func (t *loginTracker) sweep(now time.Time) {
+	for k, a := range t.attempts {
+		if a.pending == 0 && now.Sub(a.lastSeen) > t.config.MaxDelay {
+			delete(t.attempts, k)
+		}
+	}
+	t.lastSweep = now
+}
+
+// evict makes room for a record: it sweeps idle records and, if none
+// were, drops the least recently seen one without pending attempts.
+// The caller must hold t.mutex.
+func (t *loginTracker) evict(now time.Time) {
+	t.sweep(now)
+	if len(t.attempts) < t.config.MaxKeys {
+		return
+	}
+
+	var oldestKey string
+	var oldest *loginAttempts
+	for k, a := range t.attempts {
+		if a.pending == 0 && (oldest == nil || a.lastSeen.Before(oldest.lastSeen)) {
+			oldestKey, oldest = k, a
+		}
+	}
+	if oldest != nil {
+		delete(t.attempts, oldestKey)
+	}
+}
+
+// loginFailed is the default LoginThrottleConfig.Failed.
+func loginFailed(c *mux.Context, err error) bool {
+	var e *mux.Error
+	if errors.As(err, &e) {
+		return e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+	}
+	status := c.ResponseStatus()
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/obadmatar/mux"
+)
+
+func TestLoginThrottleNeutralAttempts(t *testing.T) {
+	app := mux.New(mux.Config{})
+	app.Use(LoginThrottle(LoginThrottleConfig{
+		Identifier:  func(c *mux.Context) string { return "alice" },
+		MaxAttempts: 3,
+	}))
+	app.Post("/login", mux.HandlerFunc(func(c *mux.Context) error {
+		if c.Query("password") == "" {
+			return mux.NewError(http.StatusBadRequest)
+		}
+		return mux.NewError(http.StatusUnauthorized)
+	}))
+
+	// A rejected request between wrong guesses must not reset the count.
+	targets := []string{
+		"/login?password=a", "/login?password=b", "/login",
+		"/login?password=c", "/login?password=d",
+	}
+	var codes []int
+	for _, target := range targets {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		codes = append(codes, w.Code)
+	}
+
+	want := []int{
+		http.StatusUnauthorized, http.StatusUnauthorized, http.StatusBadRequest,
+		http.StatusUnauthorized, http.StatusTooManyRequests,
+	}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Fatalf("status codes %v, want %v", codes, want)
+		}
+	}
+}
+
+func TestLoginThrottleSuccessClears(t *testing.T) {
+	app := mux.New(mux.Config{})
+	app.Use(LoginThrottle(LoginThrottleConfig{
+		Identifier:  func(c *mux.Context) string { return "alice" },
+		MaxAttempts: 2,
+	}))
+	app.Post("/login", mux.HandlerFunc(func(c *mux.Context) error {
+		if c.Query("password") != "secret" {
+			return mux.NewError(http.StatusUnauthorized)
+		}
+		return c.SendStatus(http.StatusNoContent)
+	}))
+
+	for _, target := range []string{"/login?password=a", "/login?password=secret", "/login?password=b", "/login?password=c"} {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		if w.Code == http.StatusTooManyRequests {
+			t.Fatalf("POST %s locked out after a successful login", target)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package recover provides a middleware that turns handler panics into
+// errors handled by the application's ErrorHandler.
+package recover
+
+import (
+	"errors"
+	"log"
+
+	"github.com/obadmatar/mux"
+)
+
+// Config defines the config for the recover middleware.
+type Config struct {
+	// EnableStackTrace reports the stack trace of recovered panics
+	// through StackTraceHandler.
+	//
+	// Default: false
+	EnableStackTrace bool
+
+	// StackTraceHandler receives recovered panics when EnableStackTrace is set.
+	//
+	// Default: logs the panic and its stack with the standard logger
+	StackTraceHandler func(c *mux.Context, e *mux.PanicError)
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	EnableStackTrace:  false,
+	StackTraceHandler: defaultStackTraceHandler,
+}
+
+// New creates a recover middleware. A panic in the rest of the chain is
+// returned as a *mux.PanicError, so the configured ErrorHandler answers it
+// instead of the connection being dropped.
+func New(config ...Config) mux.MiddlewareFunc {
+	cfg := configDefault(config...)
+
+	return func(next mux.Handler) mux.Handler {
+		safe := mux.WrapSafe(next)
+
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			err := safe.Handle(c)
+
+			var pe *mux.PanicError
+			if cfg.EnableStackTrace && errors.As(err, &pe) {
+				cfg.StackTraceHandler(c, pe)
+			}
+			return err
+		})
+	}
+}
+
+// configDefault returns the first config with unset fields filled from ConfigDefault.
+func configDefault(config ...Config) Config {
+	if len(config) == 0 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.StackTraceHandler == nil {
+		cfg.StackTraceHandler = ConfigDefault.StackTraceHandler
+	}
+	return cfg
+}
+
+// defaultStackTraceHandler logs the panic and its stack.
+func defaultStackTraceHandler(_ *mux.Context, e *mux.PanicError) {
+	log.Printf("recovered from %v\n%s", e, e.Stack)
+}
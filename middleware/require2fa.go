@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/obadmatar/mux"
+)
+
+// Require2FA gates routes behind a completed second factor.
+// verified reports whether the current request belongs to a user who passed
+// two-factor verification, typically by reading session state; requests for
+// which it returns false get a 403 *mux.Error.
+func Require2FA(verified func(c *mux.Context) bool) mux.MiddlewareFunc {
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			if !verified(c) {
+				return mux.NewError(http.StatusForbidden, "two-factor authentication required")
+			}
+			return next.Handle(c)
+		})
+	}
+}
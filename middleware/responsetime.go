@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/obadmatar/mux"
+)
+
+// ResponseTime sets header, "X-Response-Time" if empty, to the time the
+// rest of the chain took until the response header was sent, in
+// milliseconds with microsecond precision, e.g. "12.345ms".
+func ResponseTime(header string) mux.MiddlewareFunc {
+	if header == "" {
+		header = "X-Response-Time"
+	}
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			res := c.Response()
+			tw := &timingWriter{ResponseWriter: res, header: header, start: time.Now()}
+			c.SetResponse(tw)
+			err := next.Handle(c)
+			c.SetResponse(res)
+
+			// Nothing was sent yet, e.g. the ErrorHandler responds next.
+			tw.stamp()
+			return err
+		})
+	}
+}
+
+// timingWriter sets the response time header right before the header is sent.
+type timingWriter struct {
+	http.ResponseWriter
+
+	header  string
+	start   time.Time
+	stamped bool
+}
+
+// stamp sets the response time header once.
+func (w *timingWriter) stamp() {
+	if w.stamped {
+		return
+	}
+	w.stamped = true
+	ms := float64(time.Since(w.start).Microseconds()) / 1000
+	w.Header().Set(w.header, strconv.FormatFloat(ms, 'f', 3, 64)+"ms")
+}
+
+func (w *timingWriter) WriteHeader(code int) {
+	if code >= 200 {
+		w.stamp()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timingWriter) Write(b []byte) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher.
+func (w *timingWriter) Flush() {
+	w.stamp()
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker.
+func (w *timingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.stamped = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
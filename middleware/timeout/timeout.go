@@ -0,0 +1,211 @@
+// Package timeout provides a middleware bounding the time a route handler
+// may take.
+//
+// The middleware cancels the request context on expiry but never abandons
+// the chain: the timeout response is only sent once the handler returns. A
+// handler ignoring the cancellation keeps the client waiting for as long
+// as it runs.
+package timeout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/obadmatar/mux"
+)
+
+// ErrTimeout is the cause of the request context cancellation on expiry.
+var ErrTimeout = errors.New("timeout: handler deadline exceeded")
+
+// Config defines the config for the timeout middleware.
+type Config struct {
+	// Timeout is the time the rest of the chain may take.
+	//
+	// Default: 5s
+	Timeout time.Duration
+
+	// StatusCode is the status of the *mux.Error returned on expiry,
+	// typically 503 or 504.
+	//
+	// Default: 503
+	StatusCode int
+
+	// Next defines a function to skip this middleware when it returns true.
+	//
+	// Default: nil
+	Next func(c *mux.Context) bool
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	Timeout:    5 * time.Second,
+	StatusCode: http.StatusServiceUnavailable,
+}
+
+// New creates a timeout middleware. The request context of the rest of the
+// chain is canceled with ErrTimeout once Timeout elapses, measured with the
+// application Clock. Handlers are expected to honor the cancellation; the
+// chain is never abandoned while it runs, so the StatusCode response is
+// only sent once it returns, however late that is.
+//
+// The response is buffered until the chain returns. If the deadline has
+// passed by then, the buffered response is discarded and a *mux.Error with
+// StatusCode is returned for the ErrorHandler; writes made after the
+// deadline fail with http.ErrHandlerTimeout. Flushing commits the response:
+// what was buffered is sent and later writes go straight to the client, so
+// streams such as SSE work, but an expiry after that can only be logged by
+// the ErrorHandler. Connection hijacking is not available behind this
+// middleware.
+func New(config ...Config) mux.MiddlewareFunc {
+	cfg := configDefault(config...)
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			if cfg.Next != nil && cfg.Next(c) {
+				return next.Handle(c)
+			}
+
+			req := c.Request()
+			ctx, cancel := withTimeout(req.Context(), c.Clock(), cfg.Timeout)
+			defer cancel()
+
+			res := c.Response()
+			tw := &timeoutWriter{ctx: ctx, res: res, header: res.Header().Clone()}
+			c.SetRequest(req.WithContext(ctx))
+			c.SetResponse(tw)
+
+			err := next.Handle(c)
+
+			c.SetRequest(req)
+			c.SetResponse(res)
+
+			if errors.Is(context.Cause(ctx), ErrTimeout) {
+				return &mux.Error{
+					Code:    cfg.StatusCode,
+					Message: http.StatusText(cfg.StatusCode),
+					Err:     ErrTimeout,
+				}
+			}
+
+			// In time, send the buffered response.
+			if !tw.committed {
+				if werr := tw.send(); werr != nil && err == nil {
+					err = werr
+				}
+			}
+			return err
+		})
+	}
+}
+
+// withTimeout returns a copy of parent canceled with ErrTimeout after d on
+// clock. The system clock uses a real deadline, visible to Deadline.
+func withTimeout(parent context.Context, clock mux.Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if clock == mux.SystemClock {
+		return context.WithTimeoutCause(parent, d, ErrTimeout)
+	}
+
+	ctx, cancel := context.WithCancelCause(parent)
+	expired := clock.After(d)
+	go func() {
+		select {
+		case <-expired:
+			cancel(ErrTimeout)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// timeoutWriter buffers the response of the chain until it is flushed and
+// rejects writes after the deadline.
+type timeoutWriter struct {
+	ctx    context.Context
+	res    http.ResponseWriter
+	header http.Header
+	status int
+	buf    bytes.Buffer
+
+	// committed is set once the response was sent by a flush; later writes
+	// go straight to res.
+	committed bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.status == 0 && w.ctx.Err() == nil {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if w.ctx.Err() != nil {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.committed {
+		return w.res.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+// Flush commits the response and flushes it.
+func (w *timeoutWriter) Flush() {
+	w.FlushError()
+}
+
+// FlushError is Flush returning the error, used by http.ResponseController.
+// Past the deadline it fails with http.ErrHandlerTimeout.
+func (w *timeoutWriter) FlushError() error {
+	if w.ctx.Err() != nil {
+		return http.ErrHandlerTimeout
+	}
+	if !w.committed {
+		w.committed = true
+		if err := w.send(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.res).Flush()
+}
+
+// send writes the buffered response to res. The header of res is replaced,
+// so headers the handler deleted are gone from it too.
+func (w *timeoutWriter) send() error {
+	h := w.res.Header()
+	clear(h)
+	maps.Copy(h, w.header)
+	if w.status != 0 {
+		w.res.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		_, err := w.res.Write(w.buf.Bytes())
+		return err
+	}
+	return nil
+}
+
+// configDefault returns the first config with unset fields filled from ConfigDefault.
+func configDefault(config ...Config) Config {
+	if len(config) == 0 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = ConfigDefault.Timeout
+	}
+	if cfg.StatusCode == 0 {
+		cfg.StatusCode = ConfigDefault.StatusCode
+	}
+	return cfg
+}
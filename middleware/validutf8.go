@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/obadmatar/mux"
+)
+
+// ValidUTF8Config defines the config for ValidUTF8.
+type ValidUTF8Config struct {
+	// Next defines a function to skip the middleware when it returns true.
+	//
+	// Default: nil
+	Next func(c *mux.Context) bool
+
+	// Disallow reports whether a valid rune is rejected anyway.
+	//
+	// Default: control characters other than tab, line feed and carriage return
+	Disallow func(r rune) bool
+
+	// SkipBody only checks the path and query string, leaving the body
+	// unread.
+	//
+	// Default: false
+	SkipBody bool
+}
+
+// ValidUTF8 rejects requests whose decoded path, query values or body
+// strings contain invalid UTF-8 or runes refused by Config.Disallow with a
+// 400 *mux.Error. URL-encoded forms are checked value by value, JSON bodies
+// string by string and other text bodies as a whole; binary and multipart
+// bodies are not inspected. Inspected bodies are buffered, within the limit
+// set by mux.Config.BodyLimit, and replayed to the handler.
+func ValidUTF8(config ...ValidUTF8Config) mux.MiddlewareFunc {
+	var cfg ValidUTF8Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Disallow == nil {
+		cfg.Disallow = disallowedControl
+	}
+
+	return func(next mux.Handler) mux.Handler {
+		return mux.HandlerFunc(func(c *mux.Context) error {
+			if cfg.Next != nil && cfg.Next(c) {
+				return next.Handle(c)
+			}
+
+			req := c.Request()
+			if !validString(req.URL.Path, cfg.Disallow) {
+				return mux.NewError(http.StatusBadRequest, "invalid characters in path")
+			}
+			query, _ := url.ParseQuery(req.URL.RawQuery)
+			if !validValues(query, cfg.Disallow) {
+				return mux.NewError(http.StatusBadRequest, "invalid characters in query")
+			}
+
+			if !cfg.SkipBody && req.Body != nil && req.Body != http.NoBody {
+				if err := checkBody(req, cfg.Disallow); err != nil {
+					return err
+				}
+			}
+			return next.Handle(c)
+		})
+	}
+}
+
+// checkBody checks the text body of req and replaces it with a replay of
+// the bytes read.
+func checkBody(req *http.Request, disallow func(rune) bool) error {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get(mux.HeaderContentType))
+	check := bodyCheck(mediaType)
+	if check == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return err
+		}
+		return mux.NewError(http.StatusBadRequest)
+	}
+
+	if !utf8.Valid(body) || !check(body, disallow) {
+		return mux.NewError(http.StatusBadRequest, "invalid characters in body")
+	}
+	return nil
+}
+
+// bodyCheck returns the check of bodies of mediaType, or nil for bodies
+// that are not inspected.
+func bodyCheck(mediaType string) func([]byte, func(rune) bool) bool {
+	switch {
+	case mediaType == mux.MIMEApplicationForm:
+		return validForm
+	case mediaType == mux.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json"):
+		return validJSON
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == mux.MIMEApplicationXML || strings.HasSuffix(mediaType, "+xml"):
+		return func(body []byte, disallow func(rune) bool) bool {
+			return validString(string(body), disallow)
+		}
+	}
+	return nil
+}
+
+// validForm checks the decoded values of an URL-encoded form.
+func validForm(body []byte, disallow func(rune) bool) bool {
+	values, err := url.ParseQuery(string(body))
+	return err == nil && validValues(values, disallow)
+}
+
+// validJSON checks the decoded keys and string values of a JSON document.
+func validJSON(body []byte, disallow func(rune) bool) bool {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			// io.EOF ends the document; malformed documents are left to
+			// the decoder of the handler.
+			return true
+		}
+		if s, ok := token.(string); ok && !validString(s, disallow) {
+			return false
+		}
+	}
+}
+
+// validValues checks the keys and values of values.
+func validValues(values url.Values, disallow func(rune) bool) bool {
+	for key, list := range values {
+		if !validString(key, disallow) {
+			return false
+		}
+		for _, value := range list {
+			if !validString(value, disallow) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validString reports whether s is valid UTF-8 without disallowed runes.
+func validString(s string, disallow func(rune) bool) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if disallow(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// disallowedControl is the default ValidUTF8Config.Disallow.
+func disallowedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}
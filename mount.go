@@ -0,0 +1,39 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Mount attaches sub, an independently built App such as an admin module,
+// under prefix. Requests below prefix run the global middleware of app,
+// then are served by sub with the prefix stripped: sub applies its own
+// middleware, routes and ErrorHandler, including its 404 and 405 answers.
+// Values stored with Set are visible to sub through the request context.
+// Shutting down app also drains the streams and runs the OnShutdown hooks
+// of sub.
+func (app *App) Mount(prefix string, sub *App) *Route {
+	prefix = strings.TrimSuffix(prefix, "/")
+	stripped := http.StripPrefix(prefix, sub)
+
+	handler := HandlerFunc(func(c *Context) error {
+		stripped.ServeHTTP(c.res, c.req)
+		return nil
+	})
+
+	// A method-less subtree pattern, sub answers every method itself.
+	route := &Route{
+		app:         app,
+		path:        prefix + "/",
+		handlerName: handlerName(handler),
+		handler:     handler,
+	}
+	app.insertRoute(route)
+
+	app.mutex.Lock()
+	app.mounted = append(app.mounted, sub)
+	app.mutex.Unlock()
+
+	app.hooks.routeRegistered(route)
+	return route
+}
@@ -0,0 +1,67 @@
+package mux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Param returns the value of the path wildcard name, e.g. "id" for the
+// pattern "/users/{id}", or of the host wildcard name of routes registered
+// through App.Host. It returns "" if the route has no such wildcard.
+func (c *Context) Param(name string) string {
+	return c.req.PathValue(name)
+}
+
+// ParamInt returns the path wildcard name parsed as an int.
+func (c *Context) ParamInt(name string) (int, error) {
+	v, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		return 0, fmt.Errorf("mux: param %q: %w", name, err)
+	}
+	return v, nil
+}
+
+// ParamInt64 returns the path wildcard name parsed as an int64.
+func (c *Context) ParamInt64(name string) (int64, error) {
+	v, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("mux: param %q: %w", name, err)
+	}
+	return v, nil
+}
+
+// ParamUUID returns the path wildcard name validated as a UUID in its
+// canonical 8-4-4-4-12 hex form, normalized to lower case.
+func (c *Context) ParamUUID(name string) (string, error) {
+	v := c.Param(name)
+	if !isUUID(v) {
+		return "", fmt.Errorf("mux: param %q: invalid UUID %q", name, v)
+	}
+	return strings.ToLower(v), nil
+}
+
+// isUUID reports whether s is a UUID in canonical textual form.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isHex reports whether b is a hexadecimal digit.
+func isHex(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
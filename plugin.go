@@ -0,0 +1,91 @@
+package mux
+
+import "fmt"
+
+// Plugin bundles routes, middleware and hooks into one installable unit.
+type Plugin interface {
+	// Name returns the unique name of the plugin.
+	Name() string
+
+	// Register installs the plugin into app.
+	Register(app *App) error
+}
+
+// DependentPlugin is implemented by plugins that must be installed after
+// other plugins, for example a plugin that relies on an auth plugin.
+type DependentPlugin interface {
+	Plugin
+
+	// Dependencies returns the names of plugins that must already be installed.
+	Dependencies() []string
+}
+
+// UsePlugin installs plugins in the given order.
+// It fails if a plugin with the same name is already installed or being
+// installed, if a dependency is missing, or if Register fails or panics, for
+// example because a route conflicts with one registered earlier. Routes and
+// middleware a failed Register added before failing stay in place, and its
+// OnRoute hooks have run; only the plugin itself is not recorded as
+// installed, so the app is best discarded.
+func (app *App) UsePlugin(plugins ...Plugin) error {
+	for _, p := range plugins {
+		name := p.Name()
+		if err := app.reservePlugin(p); err != nil {
+			return err
+		}
+
+		err := registerPlugin(app, p)
+
+		app.mutex.Lock()
+		if err != nil {
+			delete(app.plugins, name)
+		} else {
+			app.plugins[name] = p
+		}
+		app.mutex.Unlock()
+
+		if err != nil {
+			return fmt.Errorf("mux: plugin %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// reservePlugin checks that p can be installed and reserves its name, with
+// a nil entry, until its registration completes. Concurrent installations
+// of plugins with the same name then fail instead of both registering.
+func (app *App) reservePlugin(p Plugin) error {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	name := p.Name()
+	if app.plugins == nil {
+		app.plugins = make(map[string]Plugin)
+	}
+	if installed, exists := app.plugins[name]; exists {
+		if installed == nil {
+			return fmt.Errorf("mux: plugin %q is being installed", name)
+		}
+		return fmt.Errorf("mux: plugin %q is already installed", name)
+	}
+	if dp, ok := p.(DependentPlugin); ok {
+		for _, dep := range dp.Dependencies() {
+			if app.plugins[dep] == nil {
+				return fmt.Errorf("mux: plugin %q requires plugin %q to be installed first", name, dep)
+			}
+		}
+	}
+	app.plugins[name] = nil
+	return nil
+}
+
+// registerPlugin runs p.Register, turning registration panics such as
+// conflicting route patterns into errors.
+func registerPlugin(app *App, p Plugin) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("register panicked: %v", r)
+		}
+	}()
+	return p.Register(app)
+}
@@ -0,0 +1,123 @@
+package mux
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"strconv"
+)
+
+// queryValues returns the parsed query string. It is parsed again only
+// when the raw query changed, e.g. after SetRequest.
+func (c *Context) queryValues() url.Values {
+	if c.query == nil || c.queryRaw != c.req.URL.RawQuery {
+		c.query = c.req.URL.Query()
+		c.queryRaw = c.req.URL.RawQuery
+	}
+	return c.query
+}
+
+// QueryArgs gives read and write access to the query string of a request.
+// Changes are written back to the request URL right away, so middleware
+// can rewrite the query without building a new http.Request. Writing
+// re-encodes the query with its keys sorted.
+type QueryArgs struct {
+	c *Context
+}
+
+// QueryArgs returns the query string of the request for reading and
+// modification.
+func (c *Context) QueryArgs() QueryArgs {
+	return QueryArgs{c: c}
+}
+
+// Get returns the first value of name, or "".
+func (q QueryArgs) Get(name string) string {
+	return q.c.queryValues().Get(name)
+}
+
+// Values returns every value of name.
+func (q QueryArgs) Values(name string) []string {
+	return q.c.queryValues()[name]
+}
+
+// Has reports whether name is present.
+func (q QueryArgs) Has(name string) bool {
+	return q.c.queryValues().Has(name)
+}
+
+// All returns a copy of the query values.
+func (q QueryArgs) All() url.Values {
+	return maps.Clone(q.c.queryValues())
+}
+
+// Set replaces the values of name with value.
+func (q QueryArgs) Set(name, value string) {
+	q.update(func(values url.Values) { values.Set(name, value) })
+}
+
+// Add appends value to the values of name.
+func (q QueryArgs) Add(name, value string) {
+	q.update(func(values url.Values) { values.Add(name, value) })
+}
+
+// Del removes name.
+func (q QueryArgs) Del(name string) {
+	q.update(func(values url.Values) { values.Del(name) })
+}
+
+// String returns the encoded query string.
+func (q QueryArgs) String() string {
+	return q.c.req.URL.RawQuery
+}
+
+// update applies fn to the query values and writes them back.
+func (q QueryArgs) update(fn func(url.Values)) {
+	values := q.c.queryValues()
+	fn(values)
+	q.c.req.URL.RawQuery = values.Encode()
+	q.c.queryRaw = q.c.req.URL.RawQuery
+}
+
+// Query returns the first value of the query parameter name, or "".
+func (c *Context) Query(name string) string {
+	return c.queryValues().Get(name)
+}
+
+// QueryDefault returns the first value of the query parameter name,
+// or def if the parameter is absent or empty.
+func (c *Context) QueryDefault(name, def string) string {
+	if v := c.Query(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// QueryInt returns the query parameter name parsed as an int.
+func (c *Context) QueryInt(name string) (int, error) {
+	v, err := strconv.Atoi(c.Query(name))
+	if err != nil {
+		return 0, fmt.Errorf("mux: query %q: %w", name, err)
+	}
+	return v, nil
+}
+
+// QueryBool returns the query parameter name parsed as a bool.
+// Accepted values are those of strconv.ParseBool.
+func (c *Context) QueryBool(name string) (bool, error) {
+	v, err := strconv.ParseBool(c.Query(name))
+	if err != nil {
+		return false, fmt.Errorf("mux: query %q: %w", name, err)
+	}
+	return v, nil
+}
+
+// Queries returns the first value of every query parameter.
+func (c *Context) Queries() map[string]string {
+	values := c.queryValues()
+	queries := make(map[string]string, len(values))
+	for name := range values {
+		queries[name] = values.Get(name)
+	}
+	return queries
+}
@@ -0,0 +1,201 @@
+package mux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Common header names.
+const (
+	HeaderContentType = "Content-Type"
+)
+
+// Common MIME types.
+const (
+	MIMEApplicationJSON            = "application/json"
+	MIMEApplicationJSONCharsetUTF8 = "application/json; charset=utf-8"
+)
+
+// jsonErrorEnvelope is sent when a JSON response cannot be encoded.
+// It has the shape of an Envelope carrying an error.
+var jsonErrorEnvelope = []byte(`{"error":{"code":500,"message":"Internal Server Error"}}` + "\n")
+
+// Envelope is the standard body of JSON responses when
+// Config.ResponseEnvelope is enabled.
+type Envelope struct {
+	// Data holds the value passed to Context.JSON.
+	Data any `json:"data,omitempty"`
+
+	// Error describes a failed request.
+	Error *EnvelopeError `json:"error,omitempty"`
+
+	// Meta holds the values added with Context.SetMeta, e.g. pagination.
+	Meta map[string]any `json:"meta,omitempty"`
+
+	// Warnings lists the messages of the warnings added with
+	// Context.AddWarning.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// EnvelopeError is the error member of an Envelope.
+type EnvelopeError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// SetMeta adds a meta value to the response envelope.
+// It has no effect unless Config.ResponseEnvelope is enabled.
+func (c *Context) SetMeta(key string, value any) {
+	if c.meta == nil {
+		c.meta = make(map[string]any)
+	}
+	c.meta[key] = value
+}
+
+// JSON encodes v as JSON and writes it with the given status code.
+// With Config.ResponseEnvelope enabled, v is wrapped in an Envelope.
+// The body is encoded into a pooled buffer first, so a partial document is
+// never sent. If encoding fails or panics, a 500 response with a minimal
+// JSON error envelope is sent instead and the encoding error is returned
+// for the ErrorHandler to report.
+func (c *Context) JSON(status int, v any) error {
+	if c.app.config.ResponseEnvelope {
+		v = Envelope{Data: v, Meta: c.meta, Warnings: c.warnings.Messages()}
+	}
+	return c.writeJSON(status, v)
+}
+
+// writeJSON encodes v as JSON as is and writes it with the given status code.
+func (c *Context) writeJSON(status int, v any) error {
+	buf := jsonBuffers.acquire()
+	defer jsonBuffers.release(buf)
+
+	if err := encodeJSON(buf, v); err != nil {
+		c.res.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+		c.res.WriteHeader(http.StatusInternalServerError)
+		c.res.Write(jsonErrorEnvelope)
+		return fmt.Errorf("mux: encode JSON response: %w", err)
+	}
+
+	c.res.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+	c.res.WriteHeader(status)
+	_, err := c.res.Write(buf.Bytes())
+	return err
+}
+
+// encodeJSON encodes v into w, turning panics raised by custom marshalers
+// into errors.
+func encodeJSON(w io.Writer, v any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return json.NewEncoder(w).Encode(v)
+}
+
+// MIMETextPlainCharsetUTF8 is the content type of UTF-8 SendString responses.
+const MIMETextPlainCharsetUTF8 = "text/plain; charset=utf-8"
+
+// Status sets the status code used by the following Send and SendString
+// calls, allowing ctx.Status(201).SendString("created").
+func (c *Context) Status(code int) *Context {
+	c.status = code
+	return c
+}
+
+// statusCode returns the code set with Status, defaulting to 200.
+func (c *Context) statusCode() int {
+	if c.status == 0 {
+		return http.StatusOK
+	}
+	return c.status
+}
+
+// Send writes body with the status set by Status.
+// The content type is sniffed by net/http unless already set.
+func (c *Context) Send(body []byte) error {
+	c.res.WriteHeader(c.statusCode())
+	_, err := c.res.Write(body)
+	return err
+}
+
+// SendString writes body as plain text with the status set by Status.
+// Unless a content type is already set, body is encoded in the charset
+// returned by Charset, transcoding it for clients that do not accept UTF-8.
+func (c *Context) SendString(body string) error {
+	if c.res.Header().Get(HeaderContentType) != "" {
+		c.res.WriteHeader(c.statusCode())
+		_, err := io.WriteString(c.res, body)
+		return err
+	}
+
+	charset := c.Charset()
+	encoded, err := EncodeString(body, charset)
+	if err != nil {
+		return err
+	}
+	c.res.Header().Set(HeaderContentType, "text/plain; charset="+charset)
+	c.res.WriteHeader(c.statusCode())
+	_, err = c.res.Write(encoded)
+	return err
+}
+
+// SendStatus writes code with its status text as the body. Statuses that
+// must not carry a body, 1xx, 204 and 304, are written without one.
+func (c *Context) SendStatus(code int) error {
+	c.status = code
+	if !bodyAllowed(code) {
+		c.res.WriteHeader(code)
+		return nil
+	}
+	return c.SendString(http.StatusText(code))
+}
+
+// bodyAllowed reports whether a response with status code may carry a body.
+func bodyAllowed(code int) bool {
+	return code >= 200 && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// NoContent writes a 204 No Content response.
+func (c *Context) NoContent() error {
+	c.res.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Text returns a Handler that writes body as plain text with status,
+// e.g. for health endpoints.
+func Text(status int, body string) Handler {
+	return HandlerFunc(func(c *Context) error {
+		return c.Status(status).SendString(body)
+	})
+}
+
+// JSONStatic returns a Handler that writes v as JSON with status.
+// v is encoded once when the handler is created and sent as is, without
+// a response envelope. It panics if v cannot be encoded.
+func JSONStatic(status int, v any) Handler {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("mux: JSONStatic: %v", err))
+	}
+	body = append(body, '\n')
+
+	return HandlerFunc(func(c *Context) error {
+		c.res.Header().Set(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+		c.res.WriteHeader(status)
+		_, err := c.res.Write(body)
+		return err
+	})
+}
+
+// RedirectTo returns a Handler that redirects to url with code,
+// e.g. http.StatusMovedPermanently.
+func RedirectTo(url string, code int) Handler {
+	return HandlerFunc(func(c *Context) error {
+		http.Redirect(c.res, c.req, url, code)
+		return nil
+	})
+}
@@ -0,0 +1,401 @@
+package mux
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Route is a registered route. It is returned by the registration methods
+// of App and Group and allows further configuration of the route.
+type Route struct {
+	// app is the application the route is registered with.
+	app *App
+
+	// host is the host pattern of routes registered through App.Host.
+	host *hostRouter
+
+	// method is the HTTP method the route responds to, a comma-separated
+	// list for routes registered with Match, or empty for every method.
+	method string
+
+	// path is the canonical path pattern, including any group prefix.
+	path string
+
+	// name is the optional name used for reverse routing.
+	name string
+
+	// prefix is the prefix of the group the route was registered through.
+	prefix string
+
+	// group is the group the route was registered through, if any.
+	group *Group
+
+	// aliases holds additional path patterns served by the route.
+	aliases []string
+
+	// headers are set on every response before the handler runs.
+	headers map[string]string
+
+	// values are static request values declared with WithValue.
+	values map[string]any
+
+	// consumes and produces list the media types declared with Consumes
+	// and Produces.
+	consumes []string
+	produces []string
+
+	// jsonOptions overrides Config.JSONDecoding when set.
+	jsonOptions *JSONOptions
+
+	// deprecation is set for routes marked with Deprecated.
+	deprecation *Deprecation
+
+	// onRequest and onResponse are the hooks of the route, run after the
+	// app-level ones and before them respectively.
+	onRequest  []func(*Context) error
+	onResponse []func(*Context)
+
+	// handlerName identifies the handler passed at registration.
+	handlerName string
+
+	// middleware identifies the group and route middleware, in order.
+	middleware []string
+
+	// handler is the route handler wrapped by route and group middleware.
+	handler Handler
+
+	// compiled caches handler wrapped by the global middleware stack.
+	compiled atomic.Pointer[chain]
+
+	// stub is the canned handler served while stubs are active.
+	stub Handler
+
+	// stubCompiled caches stub wrapped by the global middleware stack.
+	stubCompiled atomic.Pointer[chain]
+}
+
+// RouteInfo describes a registered route for introspection.
+type RouteInfo struct {
+	// Method is the HTTP method of the route, a comma-separated list for
+	// routes registered with Match, and empty for routes answering every
+	// method.
+	Method string `json:"method"`
+
+	// Host is the host pattern of the route, empty for routes answering
+	// every host.
+	Host string `json:"host,omitempty"`
+
+	// Path is the canonical path pattern of the route.
+	Path string `json:"path"`
+
+	// Aliases lists additional path patterns served by the route.
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Name is the route name, if any.
+	Name string `json:"name,omitempty"`
+
+	// Handler identifies the handler, by function name for HandlerFunc
+	// and by type otherwise.
+	Handler string `json:"handler"`
+
+	// Headers are the response header presets of the route.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Consumes lists the request media types accepted by the route.
+	Consumes []string `json:"consumes,omitempty"`
+
+	// Produces lists the response media types of the route.
+	Produces []string `json:"produces,omitempty"`
+
+	// Stubbed reports whether the route has a stub.
+	Stubbed bool `json:"stubbed,omitempty"`
+
+	// Deprecation is set for deprecated routes.
+	Deprecation *Deprecation `json:"deprecation,omitempty"`
+}
+
+// Routes returns every registered route in registration order.
+func (app *App) Routes() []RouteInfo {
+	app.mutex.Lock()
+	defer app.mutex.Unlock()
+
+	routes := make([]RouteInfo, 0, len(app.routes))
+	for _, r := range app.routes {
+		routes = append(routes, r.info())
+	}
+	return routes
+}
+
+// info returns the RouteInfo of r. The caller must hold app.mutex.
+func (r *Route) info() RouteInfo {
+	var host string
+	if r.host != nil {
+		host = r.host.pattern
+	}
+	return RouteInfo{
+		Method:      r.method,
+		Host:        host,
+		Path:        r.path,
+		Aliases:     append([]string(nil), r.aliases...),
+		Name:        r.name,
+		Handler:     r.handlerName,
+		Headers:     maps.Clone(r.headers),
+		Consumes:    slices.Clone(r.consumes),
+		Produces:    slices.Clone(r.produces),
+		Stubbed:     r.stub != nil,
+		Deprecation: r.deprecation,
+	}
+}
+
+// handlerName returns a readable identity of h.
+func handlerName(h Handler) string {
+	if f, ok := h.(HandlerFunc); ok {
+		if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// middlewareName returns a readable identity of m: the name of the
+// function that built it without package path and closure suffixes,
+// e.g. "logger.New".
+func middlewareName(m MiddlewareFunc) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(m).Pointer())
+	if fn == nil {
+		return fmt.Sprintf("%T", m)
+	}
+	name := fn.Name()
+	name = name[strings.LastIndexByte(name, '/')+1:]
+	for {
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 || !strings.HasPrefix(name[i+1:], "func") {
+			return name
+		}
+		name = name[:i]
+	}
+}
+
+// middlewareNames returns the names of middleware, in order.
+func middlewareNames(middleware []namedMiddleware) []string {
+	names := make([]string, len(middleware))
+	for i, m := range middleware {
+		names[i] = m.name
+	}
+	return names
+}
+
+// HandlerChain returns the names of the global middleware, the group and
+// route middleware, and the handler of the matched route, in execution
+// order. It helps diagnose why a middleware did or did not run; set
+// Config.ChainHeader to list the middleware actually executed per response.
+func (c *Context) HandlerChain() []string {
+	r := c.route
+	if r == nil {
+		return nil
+	}
+
+	var chain []string
+	if compiled := r.compiled.Load(); compiled != nil {
+		chain = append(chain, compiled.names...)
+	}
+	chain = append(chain, r.middleware...)
+	return append(chain, r.handlerName)
+}
+
+// Alias registers additional paths served by the same handler and middleware
+// chain as the route. Aliases of group routes are prefixed by the group prefix.
+func (r *Route) Alias(paths ...string) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	for _, path := range paths {
+		path = r.prefix + path
+		r.app.register(r, path)
+		r.aliases = append(r.aliases, path)
+	}
+	return r
+}
+
+// Headers sets response headers, e.g. Cache-Control or X-Robots-Tag, on
+// every response of the route before the handler runs. The handler can
+// still override them.
+func (r *Route) Headers(headers map[string]string) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	if r.headers == nil {
+		r.headers = make(map[string]string, len(headers))
+	}
+	for key, value := range headers {
+		r.headers[http.CanonicalHeaderKey(key)] = value
+	}
+	return r
+}
+
+// WithValue declares a static value visible to every request of the route
+// through Context.Locals and Context.Get, e.g. a required scope or a cache
+// TTL read by policy middleware. Values set during the request take
+// precedence.
+func (r *Route) WithValue(key string, value any) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	if r.values == nil {
+		r.values = make(map[string]any)
+	}
+	r.values[key] = value
+	return r
+}
+
+// OnRequest registers a hook run for every request of the route, after the
+// OnRequest hooks of the app and before the global middleware. An error
+// skips the middleware and the handler and goes to the ErrorHandler.
+func (r *Route) OnRequest(hook func(*Context) error) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	r.onRequest = append(r.onRequest, hook)
+	return r
+}
+
+// OnResponse registers a hook run for every request of the route once the
+// handler and the ErrorHandler are done, before the OnResponse hooks of
+// the app.
+func (r *Route) OnResponse(hook func(*Context)) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	r.onResponse = append(r.onResponse, hook)
+	return r
+}
+
+// request runs the OnRequest hooks of the route, stopping at the first
+// error.
+func (r *Route) request(c *Context) error {
+	for _, hook := range r.onRequest {
+		if err := hook(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// response runs the OnResponse hooks of the route.
+func (r *Route) response(c *Context) {
+	for _, hook := range r.onResponse {
+		hook(c)
+	}
+}
+
+// Stub sets a canned response, e.g. Text or JSONStatic, served instead of
+// the route handler while Config.Development is enabled or the request
+// carries Config.StubHeader. Frontend work can then proceed against routes
+// that are not implemented yet. Global middleware still applies.
+func (r *Route) Stub(response Handler) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	r.stub = response
+	r.stubCompiled.Store(nil)
+	return r
+}
+
+// Name names the route so its URL can be built with App.URL.
+// It panics if another route already has the name.
+func (r *Route) Name(name string) *Route {
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+
+	if other, exists := r.app.names[name]; exists && other != r {
+		panic(fmt.Sprintf("mux: route name %q is already used by %s %s", name, other.method, other.path))
+	}
+	if r.app.names == nil {
+		r.app.names = make(map[string]*Route)
+	}
+	if r.name != "" {
+		delete(r.app.names, r.name)
+	}
+	r.name = name
+	r.app.names[name] = r
+	return r
+}
+
+// URL builds the path of the route named name.
+// params are wildcard name and value pairs, e.g. URL("user.show", "id", 42).
+// Values are formatted with fmt.Sprint and escaped; a trailing {name...}
+// wildcard keeps slashes in its value, and an optional {name?} wildcard
+// without a value is left out.
+func (app *App) URL(name string, params ...any) (string, error) {
+	app.mutex.Lock()
+	route, ok := app.names[name]
+	app.mutex.Unlock()
+	if !ok {
+		return "", fmt.Errorf("mux: no route named %q", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("mux: URL %q: params must be name and value pairs", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key, ok := params[i].(string)
+		if !ok {
+			return "", fmt.Errorf("mux: URL %q: param name %v is not a string", name, params[i])
+		}
+		values[key] = fmt.Sprint(params[i+1])
+	}
+
+	segments, err := splitPattern(strings.TrimPrefix(route.path, "/"))
+	if err != nil {
+		return "", fmt.Errorf("mux: URL %q: malformed pattern %q", name, route.path)
+	}
+
+	var sb strings.Builder
+	for _, s := range segments {
+		if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+			sb.WriteString("/" + s)
+			continue
+		}
+		seg, err := parseWildcard(s[1 : len(s)-1])
+		if err != nil {
+			return "", fmt.Errorf("mux: URL %q: malformed pattern %q", name, route.path)
+		}
+
+		// {$} only anchors the end of the path.
+		if seg.kind == segmentLiteral {
+			sb.WriteString("/")
+			continue
+		}
+
+		value, ok := values[seg.value]
+		if !ok {
+			if seg.optional {
+				continue
+			}
+			return "", fmt.Errorf("mux: URL %q: missing param %q", name, seg.value)
+		}
+		delete(values, seg.value)
+
+		if seg.kind == segmentCatchAll {
+			sb.WriteString("/" + (&url.URL{Path: value}).EscapedPath())
+		} else {
+			sb.WriteString("/" + url.PathEscape(value))
+		}
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("/")
+	}
+
+	for key := range values {
+		return "", fmt.Errorf("mux: URL %q: unknown param %q", name, key)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,615 @@
+package mux
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Route patterns are matched segment by segment against the request path:
+//
+//	/users              a literal path
+//	/users/{id}         a wildcard matching one non-empty segment
+//	/users/{id:[0-9]+}  a wildcard constrained by a regular expression
+//	/users/{id:int}     a wildcard constrained by a named Constraint
+//	/users/{id?}        an optional last wildcard, matching /users as well
+//	/files/{path...}    a catch-all capturing the rest of the path
+//	/static/            a subtree, matching everything below /static/
+//	/posts/{$}          the path /posts/ only
+//
+// When several patterns match a request, literal segments win over
+// constrained wildcards, which win over plain wildcards, which win over
+// catch-alls, segment by segment from the left. Wildcards at the same
+// position are tried in registration order.
+
+// segmentKind is the kind of a pattern segment.
+type segmentKind int
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentWildcard
+	segmentCatchAll
+)
+
+// segment is a parsed pattern segment.
+type segment struct {
+	kind segmentKind
+
+	// value is the literal, or the wildcard name. Subtree catch-alls are
+	// anonymous.
+	value string
+
+	// constraint is the source of the constraint of a wildcard: a
+	// constraint name or a regular expression.
+	constraint string
+
+	// optional marks a last wildcard that may be left out.
+	optional bool
+}
+
+// parsePattern splits pattern into segments.
+func parsePattern(pattern string) ([]segment, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("pattern %q must begin with '/'", pattern)
+	}
+
+	raw, err := splitPattern(pattern[1:])
+	if err != nil {
+		return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+	}
+
+	segments := make([]segment, 0, len(raw))
+	names := make(map[string]bool)
+	for i, s := range raw {
+		last := i == len(raw)-1
+		if !strings.Contains(s, "{") {
+			if strings.Contains(s, "}") {
+				return nil, fmt.Errorf("pattern %q: unmatched '}'", pattern)
+			}
+			if last && s == "" {
+				// A trailing slash matches the whole subtree.
+				segments = append(segments, segment{kind: segmentCatchAll})
+				continue
+			}
+			segments = append(segments, segment{kind: segmentLiteral, value: s})
+			continue
+		}
+
+		if s[0] != '{' || s[len(s)-1] != '}' {
+			return nil, fmt.Errorf("pattern %q: wildcard must be a whole segment", pattern)
+		}
+		seg, err := parseWildcard(s[1 : len(s)-1])
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+
+		switch {
+		case seg.kind == segmentLiteral:
+			// {$} anchors the end of a path ending with a slash.
+			if !last {
+				return nil, fmt.Errorf("pattern %q: {$} must be the last segment", pattern)
+			}
+		case (seg.kind == segmentCatchAll || seg.optional) && !last:
+			return nil, fmt.Errorf("pattern %q: {%s} must be the last segment", pattern, s[1:len(s)-1])
+		case names[seg.value]:
+			return nil, fmt.Errorf("pattern %q: duplicate wildcard %q", pattern, seg.value)
+		}
+		names[seg.value] = true
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// splitPattern splits p at the slashes outside of braces, so constraints
+// may contain slashes inside brackets or braces of their own.
+func splitPattern(p string) ([]string, error) {
+	var segments []string
+	depth, start := 0, 0
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return nil, fmt.Errorf("unmatched '}'")
+			}
+			depth--
+		case '/':
+			if depth == 0 {
+				segments = append(segments, p[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unclosed '{'")
+	}
+	return append(segments, p[start:]), nil
+}
+
+// parseWildcard parses the inside of a {...} segment.
+func parseWildcard(s string) (segment, error) {
+	if s == "$" {
+		return segment{kind: segmentLiteral}, nil
+	}
+
+	name, constraint, constrained := strings.Cut(s, ":")
+	seg := segment{kind: segmentWildcard, constraint: constraint}
+	if constrained && constraint == "" {
+		return seg, fmt.Errorf("wildcard %q has an empty constraint", name)
+	}
+	if n, ok := strings.CutSuffix(name, "..."); ok {
+		if constrained {
+			return seg, fmt.Errorf("catch-all %q cannot be constrained", n)
+		}
+		seg.kind, name = segmentCatchAll, n
+	} else if n, ok := strings.CutSuffix(name, "?"); ok {
+		seg.optional, name = true, n
+	}
+	if !validWildcardName(name) {
+		return seg, fmt.Errorf("bad wildcard name %q", name)
+	}
+	seg.value = name
+	return seg, nil
+}
+
+// validWildcardName reports whether name is a Go identifier.
+func validWildcardName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// expandOptional returns the segment lists matched by segments: itself,
+// and without its last segment when that one is optional.
+func expandOptional(segments []segment) [][]segment {
+	if len(segments) == 0 || !segments[len(segments)-1].optional {
+		return [][]segment{segments}
+	}
+	without := segments[:len(segments)-1]
+	if len(without) == 0 {
+		// "/{name?}" also matches "/".
+		without = []segment{{kind: segmentLiteral}}
+	}
+	return [][]segment{segments, without}
+}
+
+// node is a node of the routing trie, one per pattern segment.
+type node struct {
+	// segment is the pattern segment of the node.
+	segment segment
+
+	// constraint is the compiled segment constraint.
+	constraint Constraint
+
+	// literals maps literal segments to their children.
+	literals map[string]*node
+
+	// wildcards lists the single segment wildcard children, constrained
+	// ones first, each group in registration order.
+	wildcards []*node
+
+	// catchAlls lists the catch-all children in registration order.
+	catchAlls []*node
+
+	// routes maps methods to the routes ending at the node, with "" for
+	// routes answering every method.
+	routes map[string]*Route
+
+	// patterns maps methods to the registered patterns of routes.
+	patterns map[string]string
+}
+
+// clone returns a copy of n that can be changed without affecting the
+// lookups reading n.
+func (n *node) clone() *node {
+	c := *n
+	c.literals = maps.Clone(n.literals)
+	c.wildcards = slices.Clone(n.wildcards)
+	c.catchAlls = slices.Clone(n.catchAlls)
+	c.routes = maps.Clone(n.routes)
+	c.patterns = maps.Clone(n.patterns)
+	return &c
+}
+
+// route returns the route of n answering method. GET routes answer HEAD
+// requests unless a HEAD route exists.
+func (n *node) route(method string) (*Route, string) {
+	if r, ok := n.routes[method]; ok {
+		return r, n.patterns[method]
+	}
+	if r, ok := n.routes[http.MethodGet]; ok && method == http.MethodHead {
+		return r, n.patterns[http.MethodGet]
+	}
+	if r, ok := n.routes[""]; ok {
+		return r, n.patterns[""]
+	}
+	return nil, ""
+}
+
+// child returns a copy of the child of n for seg, put in place of the
+// original, or a new child if there is none. n must be a copy not
+// published yet. The caller must hold rt.mutex.
+func (rt *router) child(n *node, seg segment) (*node, error) {
+	switch seg.kind {
+	case segmentLiteral:
+		if child, ok := n.literals[seg.value]; ok {
+			child = child.clone()
+			n.literals[seg.value] = child
+			return child, nil
+		}
+		if n.literals == nil {
+			n.literals = make(map[string]*node)
+		}
+		child := &node{segment: seg}
+		n.literals[seg.value] = child
+		return child, nil
+
+	case segmentCatchAll:
+		for i, child := range n.catchAlls {
+			if child.segment.value == seg.value {
+				child = child.clone()
+				n.catchAlls[i] = child
+				return child, nil
+			}
+		}
+		child := &node{segment: seg}
+		n.catchAlls = append(n.catchAlls, child)
+		return child, nil
+	}
+
+	for i, child := range n.wildcards {
+		if child.segment.value == seg.value && child.segment.constraint == seg.constraint {
+			child = child.clone()
+			n.wildcards[i] = child
+			return child, nil
+		}
+	}
+	child := &node{segment: seg}
+	if seg.constraint != "" {
+		constraint, err := rt.constraint(seg.constraint)
+		if err != nil {
+			return nil, fmt.Errorf("wildcard %q: %w", seg.value, err)
+		}
+		child.constraint = constraint
+	}
+	// Keep constrained wildcards ahead of plain ones.
+	i := len(n.wildcards)
+	if child.constraint != nil {
+		i = slices.IndexFunc(n.wildcards, func(w *node) bool { return w.constraint == nil })
+		if i < 0 {
+			i = len(n.wildcards)
+		}
+	}
+	n.wildcards = slices.Insert(n.wildcards, i, child)
+	return child, nil
+}
+
+// pathParam is a wildcard value captured while matching.
+type pathParam struct {
+	name, value string
+}
+
+// matcher walks the trie for a request path.
+type matcher struct {
+	// method is the request method the match must have a route for.
+	method string
+
+	// methods, if set, collects the methods of every matching route
+	// instead; no match is then returned.
+	methods map[string]bool
+
+	// params holds the wildcard values of the current match.
+	params []pathParam
+
+	// rest is the escaped path matched by the catch-all of the match.
+	rest string
+}
+
+// accept reports whether the node n matching the path is the result.
+func (m *matcher) accept(n *node) bool {
+	if m.methods != nil {
+		for method := range n.routes {
+			m.methods[method] = true
+		}
+		// Keep exploring every matching pattern.
+		return false
+	}
+	r, _ := n.route(m.method)
+	return r != nil
+}
+
+// match returns the node below n matching the escaped path rest, without
+// its leading slash, and accepted by accept. more is false once every
+// segment of the path was matched.
+func (m *matcher) match(n *node, rest string, more bool) *node {
+	if !more {
+		if n.routes != nil && m.accept(n) {
+			return n
+		}
+		return nil
+	}
+
+	escaped, tail, next := strings.Cut(rest, "/")
+	seg := unescapeSegment(escaped)
+	if child, ok := n.literals[seg]; ok {
+		if found := m.match(child, tail, next); found != nil {
+			return found
+		}
+	}
+	if seg != "" {
+		for _, child := range n.wildcards {
+			if child.constraint != nil && !child.constraint(seg) {
+				continue
+			}
+			m.addParam(child.segment.value, seg)
+			if found := m.match(child, tail, next); found != nil {
+				return found
+			}
+			m.params = m.params[:len(m.params)-1]
+		}
+	}
+	for _, child := range n.catchAlls {
+		if child.routes == nil || !m.accept(child) {
+			continue
+		}
+		if child.segment.value != "" {
+			m.addParam(child.segment.value, unescapePath(rest))
+		}
+		m.rest = rest
+		return child
+	}
+	return nil
+}
+
+// addParam appends a wildcard value, allocating room for a few at once.
+func (m *matcher) addParam(name, value string) {
+	if m.params == nil {
+		m.params = make([]pathParam, 0, 4)
+	}
+	m.params = append(m.params, pathParam{name, value})
+}
+
+// router is the routing trie of an App.
+type router struct {
+	// root is the trie, matching the first path segment. Lookups read it
+	// without locking, so changes publish a new trie sharing the
+	// unchanged nodes rather than modify it.
+	root atomic.Pointer[node]
+
+	// mutex serializes changes and protects the fields below.
+	mutex sync.Mutex
+
+	// constraints holds the constraints registered with
+	// App.RegisterConstraint.
+	constraints map[string]Constraint
+
+	// keys maps the method and wildcard-free shape of every pattern to its
+	// route, to detect patterns that can never match.
+	keys map[string]*Route
+}
+
+// emptyTrie is the trie of a router without routes. It is never modified.
+var emptyTrie = &node{}
+
+// trie returns the current trie.
+func (rt *router) trie() *node {
+	if root := rt.root.Load(); root != nil {
+		return root
+	}
+	return emptyTrie
+}
+
+// add registers route for method and pattern. An empty method matches
+// every method.
+func (rt *router) add(method, pattern string, route *Route) error {
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	variants := expandOptional(segments)
+	for _, segments := range variants {
+		key := method + " " + patternShape(segments)
+		if other, ok := rt.keys[key]; ok {
+			return fmt.Errorf("pattern %q conflicts with route %s %s", pattern, other.method, other.path)
+		}
+	}
+
+	root := rt.trie().clone()
+	for _, segments := range variants {
+		n := root
+		for _, seg := range segments {
+			if n, err = rt.child(n, seg); err != nil {
+				return fmt.Errorf("pattern %q: %w", pattern, err)
+			}
+		}
+		if n.routes == nil {
+			n.routes = make(map[string]*Route)
+			n.patterns = make(map[string]string)
+		}
+		n.routes[method] = route
+		n.patterns[method] = strings.TrimSpace(method + " " + pattern)
+	}
+	rt.root.Store(root)
+
+	if rt.keys == nil {
+		rt.keys = make(map[string]*Route)
+	}
+	for _, segments := range variants {
+		rt.keys[method+" "+patternShape(segments)] = route
+	}
+	return nil
+}
+
+// check reports the error add would return for method and pattern,
+// without registering anything. pending holds the keys of the patterns
+// checked for a batch so far, so they conflict with each other too.
+func (rt *router) check(method, pattern string, pending map[string]bool) error {
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	for _, seg := range segments {
+		if seg.constraint == "" {
+			continue
+		}
+		if _, err := rt.constraint(seg.constraint); err != nil {
+			return fmt.Errorf("pattern %q: wildcard %q: %w", pattern, seg.value, err)
+		}
+	}
+
+	variants := expandOptional(segments)
+	for _, segments := range variants {
+		key := method + " " + patternShape(segments)
+		if other, ok := rt.keys[key]; ok {
+			return fmt.Errorf("pattern %q conflicts with route %s %s", pattern, other.method, other.path)
+		}
+		if pending[key] {
+			return fmt.Errorf("pattern %q conflicts with another route of the batch", pattern)
+		}
+	}
+	for _, segments := range variants {
+		pending[method+" "+patternShape(segments)] = true
+	}
+	return nil
+}
+
+// patternShape returns segments as a pattern with wildcard names removed.
+func patternShape(segments []segment) string {
+	var sb strings.Builder
+	for _, seg := range segments {
+		sb.WriteByte('/')
+		switch seg.kind {
+		case segmentLiteral:
+			sb.WriteString(seg.value)
+		case segmentWildcard:
+			sb.WriteString("{:" + seg.constraint + "}")
+		case segmentCatchAll:
+			sb.WriteString("{...}")
+		}
+	}
+	return sb.String()
+}
+
+// routeMatch is the result of a lookup.
+type routeMatch struct {
+	route   *Route
+	pattern string
+	params  []pathParam
+
+	// exact is false for catch-alls matching more than an empty segment.
+	exact bool
+}
+
+// lookup returns the route serving method for the escaped path.
+func (rt *router) lookup(method, escapedPath string) (routeMatch, bool) {
+	m := matcher{method: method}
+	n := m.match(rt.trie(), strings.TrimPrefix(escapedPath, "/"), true)
+	if n == nil {
+		return routeMatch{}, false
+	}
+
+	route, pattern := n.route(method)
+	return routeMatch{
+		route:   route,
+		pattern: pattern,
+		params:  m.params,
+		exact:   n.segment.kind != segmentCatchAll || m.rest == "",
+	}, true
+}
+
+// methods adds the methods with a route matching the escaped path to
+// methods, with "" for routes answering every method.
+func (rt *router) methods(escapedPath string, methods map[string]bool) {
+	m := matcher{methods: methods}
+	m.match(rt.trie(), strings.TrimPrefix(escapedPath, "/"), true)
+}
+
+// allowHeader returns the methods of the Allow header for the methods
+// with a matching route, including HEAD for GET routes and OPTIONS, which
+// are answered automatically.
+func allowHeader(methods map[string]bool) []string {
+	if methods[http.MethodGet] {
+		methods[http.MethodHead] = true
+	}
+	if len(methods) > 0 {
+		methods[http.MethodOptions] = true
+	}
+	delete(methods, "")
+
+	var allowed []string
+	for _, method := range probeMethods {
+		if methods[method] {
+			allowed = append(allowed, method)
+			delete(methods, method)
+		}
+	}
+	return append(allowed, sortedKeys(methods)...)
+}
+
+// probeMethods orders the methods of the Allow header.
+var probeMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+	http.MethodConnect, http.MethodTrace,
+}
+
+// unescapeSegment unescapes an escaped path segment, leaving it as is if
+// it is not validly escaped.
+func unescapeSegment(seg string) string {
+	if strings.IndexByte(seg, '%') >= 0 {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			return unescaped
+		}
+	}
+	return seg
+}
+
+// unescapePath unescapes an escaped path segment by segment, leaving the
+// segments that are not validly escaped as is.
+func unescapePath(escapedPath string) string {
+	if strings.IndexByte(escapedPath, '%') < 0 {
+		return escapedPath
+	}
+	segs := strings.Split(escapedPath, "/")
+	for i, seg := range segs {
+		segs[i] = unescapeSegment(seg)
+	}
+	return strings.Join(segs, "/")
+}
+
+// cleanPath returns the canonical path for p, eliminating . and ..
+// elements and keeping a trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
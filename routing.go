@@ -1,54 +1,100 @@
 package mux
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Get registers a GET route with the given path and handler.
-func (app *App) Get(path string, handler Handler, middleware ...MiddlewareFunc) {
-	app.addRoute("GET", path, handler, middleware...)
+// The route also answers HEAD requests, with the headers only, unless a
+// HEAD route is registered for the path.
+func (app *App) Get(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute("GET", path, handler, nameMiddleware(middleware))
 }
 
 // Post registers a POST route with the given path and handler.
-func (app *App) Post(path string, handler Handler, middleware ...MiddlewareFunc) {
-	app.addRoute("POST", path, handler, middleware...)
+func (app *App) Post(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute("POST", path, handler, nameMiddleware(middleware))
 }
 
 // Put registers a PUT route with the given path and handler.
-func (app *App) Put(path string, handler Handler, middleware ...MiddlewareFunc) {
-	app.addRoute("PUT", path, handler, middleware...)
+func (app *App) Put(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute("PUT", path, handler, nameMiddleware(middleware))
 }
 
 // Delete registers a DELETE route with the given path and handler.
-func (app *App) Delete(path string, handler Handler, middleware ...MiddlewareFunc) {
-	app.addRoute("DELETE", path, handler, middleware...)
+func (app *App) Delete(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute("DELETE", path, handler, nameMiddleware(middleware))
 }
 
 // Patch registers a PATCH route with the given path and handler.
-func (app *App) Patch(path string, handler Handler, middleware ...MiddlewareFunc) {
-	app.addRoute("PATCH", path, handler, middleware...)
+func (app *App) Patch(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute("PATCH", path, handler, nameMiddleware(middleware))
 }
 
-// Head registers a HEAD route with the given path and handler.
-func (app *App) Head(path string, handler Handler, middleware ...MiddlewareFunc) {
-	app.addRoute("HEAD", path, handler, middleware...)
+// Head registers a HEAD route with the given path and handler, taking
+// over HEAD requests from the GET route of the path.
+func (app *App) Head(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute("HEAD", path, handler, nameMiddleware(middleware))
 }
 
 // Options registers an OPTIONS route with the given path and handler.
-func (app *App) Options(path string, handler Handler, middleware ...MiddlewareFunc) {
-	app.addRoute("OPTIONS", path, handler, middleware...)
+// Without one, OPTIONS requests are answered by Config.OptionsHandler.
+func (app *App) Options(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute("OPTIONS", path, handler, nameMiddleware(middleware))
+}
+
+// All registers a route answering every HTTP method with the given path
+// and handler.
+func (app *App) All(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute("", path, handler, nameMiddleware(middleware))
+}
+
+// Match registers a route answering the given HTTP methods, e.g.
+// []string{"GET", "POST"}, with the given path and handler.
+func (app *App) Match(methods []string, path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return app.addRoute(joinMethods(methods), path, handler, nameMiddleware(middleware))
+}
+
+// joinMethods returns methods as the comma-separated method of a route.
+// It panics if methods is empty.
+func joinMethods(methods []string) string {
+	if len(methods) == 0 {
+		panic("mux: Match requires at least one method")
+	}
+	upper := make([]string, len(methods))
+	for i, method := range methods {
+		upper[i] = strings.ToUpper(method)
+	}
+	return strings.Join(upper, ",")
 }
 
 // Use adds middleware to the application.
-// Middleware will be applied to all routes registered after this call.
+// Middleware applies to every route, including routes registered before this call.
 func (app *App) Use(middleware ...MiddlewareFunc) {
 	app.mutex.Lock()
 	defer app.mutex.Unlock()
+	app.pushMiddleware(nameMiddleware(middleware))
+}
 
-	if app.middleware == nil {
-		app.middleware = make([]MiddlewareFunc, 0)
-	}
-	app.middleware = append(app.middleware, middleware...)
+// pushMiddleware appends middleware to the global stack.
+// The caller must hold app.mutex.
+func (app *App) pushMiddleware(middleware []namedMiddleware) {
+	// Copy on write, readers may still hold the previous stack.
+	current := *app.middleware.Load()
+	stack := make([]namedMiddleware, 0, len(current)+len(middleware))
+	stack = append(stack, current...)
+	stack = append(stack, middleware...)
+	app.middleware.Store(&stack)
 }
 
 // Group creates a new route group with optional middleware.
@@ -57,155 +103,664 @@ func (app *App) Group(prefix string, middleware ...MiddlewareFunc) *Group {
 	return &Group{
 		app:        app,
 		prefix:     prefix,
-		middleware: middleware,
+		middleware: nameMiddleware(middleware),
 	}
 }
 
-// addRoute is an internal method that registers a route with the ServeMux.
-func (app *App) addRoute(method, path string, handler Handler, middleware ...MiddlewareFunc) {
+// addRoute is an internal method that registers a route with the router.
+func (app *App) addRoute(method, path string, handler Handler, middleware []namedMiddleware) *Route {
+	return app.addHostRoute(nil, method, path, handler, middleware)
+}
+
+// addHostRoute registers a route for the host pattern of host, or for
+// every host when host is nil.
+func (app *App) addHostRoute(host *hostRouter, method, path string, handler Handler, middleware []namedMiddleware) *Route {
+	route := app.newRoute(host, method, path, handler, middleware)
+	app.insertRoute(route)
+	app.hooks.routeRegistered(route)
+	return route
+}
+
+// newRoute creates a route without registering it.
+func (app *App) newRoute(host *hostRouter, method, path string, handler Handler, middleware []namedMiddleware) *Route {
+	// Apply route-specific middleware once, global middleware is applied
+	// lazily since it may change after registration.
+	return &Route{
+		app:         app,
+		host:        host,
+		method:      method,
+		path:        path,
+		handlerName: handlerName(handler),
+		middleware:  middlewareNames(middleware),
+		handler:     app.applyMiddleware(middleware, handler),
+	}
+}
+
+// insertRoute registers route for its path and lists it in app.routes.
+func (app *App) insertRoute(route *Route) {
 	app.mutex.Lock()
 	defer app.mutex.Unlock()
 
-	// Create the route pattern for ServeMux (method + path)
-	pattern := method + " " + path
+	app.register(route, route.path)
+	app.routes = append(app.routes, route)
+}
+
+// register adds path as a pattern of route for its methods. It panics if
+// path is malformed or conflicts with a registered pattern.
+// The caller must hold app.mutex.
+func (app *App) register(route *Route, path string) {
+	rt := &app.router
+	if route.host != nil {
+		rt = &route.host.router
+	}
+	for method := range strings.SplitSeq(route.method, ",") {
+		if err := rt.add(method, path, route); err != nil {
+			panic("mux: " + err.Error())
+		}
+	}
+}
 
-	// Wrap the handler to work with http.ServeMux
-	app.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
-		// Get a context from the pool
-		ctx := app.acquireContext(r, w)
-		defer app.releaseContext(ctx)
+// serve runs the route for a request matched by the router.
+func (r *Route) serve(w http.ResponseWriter, req *http.Request) {
+	app := r.app
+	start := time.Now()
 
-		// Apply route-specific middleware first, then global middleware
-		finalHandler := handler
+	// Get a context from the pool
+	ctx := app.acquireContext(req, w)
+	defer app.releaseContext(ctx)
+	ctx.route = r
 
-		// Apply route-specific middleware (in reverse order)
-		for i := len(middleware) - 1; i >= 0; i-- {
-			finalHandler = middleware[i](finalHandler)
-		}
+	finalHandler := r.handlerFor(req)
+
+	// Apply the response header presets of the route
+	for key, value := range r.headers {
+		w.Header()[key] = []string{value}
+	}
+	if r.deprecation != nil {
+		r.deprecation.announce(ctx)
+	}
+
+	// Execute the handler unless a hook fails or the body is over the limit
+	err := app.hooks.request(ctx)
+	if err == nil {
+		err = r.request(ctx)
+	}
+	if err == nil {
+		err = app.limitBody(ctx)
+	}
+	if err == nil {
+		err = r.checkConsumes(ctx)
+	}
+	if err == nil {
+		err = finalHandler.Handle(ctx)
+	}
+	ctx.handlerReturned()
+	if err != nil && !ctx.errorHandled {
+		// Use the error handler of the nearest group, or the configured one
+		app.errorHandler(ctx)(ctx, err)
+	}
+	r.response(ctx)
+	app.hooks.response(ctx)
+
+	app.requests.record(ctx.writer.Status(), err, time.Since(start))
+}
+
+// handlerFor returns the compiled handler serving req: the stub while
+// stubs are active for the request, and the route handler otherwise.
+func (r *Route) handlerFor(req *http.Request) Handler {
+	if r.stub != nil && r.app.stubActive(req) {
+		return r.app.compile(&r.stubCompiled, r.stub)
+	}
+	return r.app.compile(&r.compiled, r.handler)
+}
 
-		// Apply global middleware
-		finalHandler = app.applyMiddleware(finalHandler)
+// stubActive reports whether route stubs are served for req.
+func (app *App) stubActive(req *http.Request) bool {
+	if app.config.Development {
+		return true
+	}
+	return app.config.StubHeader != "" && req.Header.Get(app.config.StubHeader) != ""
+}
 
-		// Execute the handler
-		if err := finalHandler.Handle(ctx); err != nil {
-			// Use the configured error handler
-			app.config.ErrorHandler(ctx, err)
+// limitBody enforces Config.BodyLimit on the request body.
+// Bodies declared too large are rejected up front; others are wrapped so
+// reading past the limit fails with *http.MaxBytesError.
+func (app *App) limitBody(ctx *Context) error {
+	req := ctx.req
+	limit := int64(app.config.BodyLimit)
+
+	if limit < 0 {
+		// -1 declines any body.
+		if req.ContentLength != 0 {
+			return NewError(http.StatusRequestEntityTooLarge)
 		}
-	})
+		return nil
+	}
+	if req.ContentLength > limit {
+		return NewError(http.StatusRequestEntityTooLarge)
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = http.MaxBytesReader(ctx.res, req.Body, limit)
+	}
+	return nil
+}
+
+// chain is a route handler compiled against a specific global middleware stack.
+type chain struct {
+	// middleware is the global stack the handler was compiled against.
+	middleware *[]namedMiddleware
+
+	// handler is the route handler wrapped by the global middleware.
+	handler Handler
+
+	// names identifies the global middleware, for Context.HandlerChain.
+	names []string
 }
 
-// applyMiddleware applies all registered middleware to a handler.
-func (app *App) applyMiddleware(handler Handler) Handler {
+// compile returns handler wrapped by the current global middleware stack.
+// The result is cached until Use replaces the stack, so the hot path
+// neither locks nor allocates.
+func (app *App) compile(cache *atomic.Pointer[chain], handler Handler) Handler {
+	stack := app.middleware.Load()
+	if c := cache.Load(); c != nil && c.middleware == stack {
+		return c.handler
+	}
+
+	c := &chain{
+		middleware: stack,
+		handler:    app.applyMiddleware(*stack, handler),
+		names:      middlewareNames(*stack),
+	}
+	cache.Store(c)
+	return c.handler
+}
+
+// applyMiddleware wraps handler with the given middleware.
+// With Config.ChainHeader set, each middleware is traced as it runs.
+func (app *App) applyMiddleware(middleware []namedMiddleware, handler Handler) Handler {
 	// Apply middleware in reverse order (last registered, first executed)
-	for i := len(app.middleware) - 1; i >= 0; i-- {
-		handler = app.middleware[i](handler)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		m := middleware[i].fn
+		if app.config.ChainHeader != "" {
+			m = traceMiddleware(app.config.ChainHeader, middleware[i].name, m)
+		}
+		handler = m(handler)
 	}
 	return handler
 }
 
+// traceMiddleware wraps m so that name is added to the response header
+// when m runs, listing the executed chain in order.
+func traceMiddleware(header, name string, m MiddlewareFunc) MiddlewareFunc {
+	return func(next Handler) Handler {
+		h := m(next)
+		return HandlerFunc(func(c *Context) error {
+			c.res.Header().Add(header, name)
+			return h.Handle(c)
+		})
+	}
+}
+
 // acquireContext gets a Context from the pool and initializes it.
 func (app *App) acquireContext(req *http.Request, res http.ResponseWriter) *Context {
 	ctx := app.pool.Get().(*Context)
 	ctx.app = app
 	ctx.req = req
-	ctx.res = res
+	ctx.writer.reset(res)
+	ctx.res = &ctx.writer
 	return ctx
 }
 
 // releaseContext returns a Context to the pool after cleaning it.
 func (app *App) releaseContext(ctx *Context) {
+	// Return hooks are still pending if the handler panicked.
+	ctx.handlerReturned()
+	for _, fn := range ctx.releaseHooks {
+		fn()
+	}
+	clear(ctx.releaseHooks)
+	ctx.releaseHooks = ctx.releaseHooks[:0]
+
 	// Clear references to prevent memory leaks
 	ctx.app = nil
 	ctx.req = nil
 	ctx.res = nil
+	ctx.writer.reset(nil)
+	ctx.route = nil
+	clear(ctx.warnings)
+	ctx.warnings = ctx.warnings[:0]
+	ctx.adapterErr = nil
+	ctx.errorHandled = false
+	ctx.baggage = nil
+	ctx.query = nil
+	ctx.queryRaw = ""
+	ctx.status = 0
+	ctx.meta = nil
+	clear(ctx.locals)
+	clear(ctx.memo)
 	app.pool.Put(ctx)
 }
 
 // ServeHTTP implements http.Handler interface, making App compatible with http.Server.
 func (app *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if app.mux != nil {
-		app.mux.ServeHTTP(w, r)
+	if r, ok := app.admit(w, r); ok {
+		app.dispatch(w, r)
+	}
+}
+
+// admit prepares r for dispatch. During shutdown it answers r with a 503
+// instead and reports false.
+func (app *App) admit(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if app.drainEnd.Load() != 0 {
+		app.unavailable.serve(w, r)
+		return r, false
+	}
+
+	// Only a custom clock is attached, ClockFrom defaults to SystemClock.
+	if app.config.Clock != SystemClock {
+		r = r.WithContext(WithClock(r.Context(), app.config.Clock))
+	}
+	return r, true
+}
+
+// dispatch serves r with the route matching its method and path.
+// Paths that are not canonical, and paths missing the trailing slash of a
+// pattern, are redirected first.
+func (app *App) dispatch(w http.ResponseWriter, r *http.Request) {
+	host, path := requestHost(r), r.URL.EscapedPath()
+	// CONNECT requests are not canonicalized.
+	if r.Method != http.MethodConnect {
+		if cleaned := cleanPath(path); cleaned != path {
+			redirectPath(w, r, cleaned)
+			return
+		}
+	}
+
+	m, ok := app.lookup(host, r.Method, path)
+	if (!ok || !m.exact) && path != "" && !strings.HasSuffix(path, "/") {
+		if slashed, found := app.lookup(host, r.Method, path+"/"); found && slashed.exact {
+			redirectPath(w, r, path+"/")
+			return
+		}
+	}
+	if !ok {
+		app.serveUnmatched(w, r, host, path)
+		return
+	}
+	m.serve(w, r)
+}
+
+// serve runs the matched route for r, exposing the wildcard values
+// through Request.PathValue.
+func (m routeMatch) serve(w http.ResponseWriter, r *http.Request) {
+	m.bind(r)
+	m.route.serve(w, r)
+}
+
+// forward runs the middleware chain and handler of the route for r on
+// behalf of c, whose dispatch keeps running the hooks, the ErrorHandler
+// and the accounting of the request. It returns the handler error.
+func (m routeMatch) forward(c *Context, r *http.Request) error {
+	m.bind(r)
+	app := m.route.app
+	ctx := app.acquireContext(r, c.res)
+	defer app.releaseContext(ctx)
+	ctx.route = m.route
+
+	err := m.route.handlerFor(r).Handle(ctx)
+	ctx.handlerReturned()
+	if ctx.errorHandled {
+		c.errorHandled = true
+	}
+	return err
+}
+
+// bind sets the path values and pattern of the match on r.
+func (m routeMatch) bind(r *http.Request) {
+	for _, p := range m.params {
+		r.SetPathValue(p.name, p.value)
+	}
+	r.Pattern = m.pattern
+}
+
+// redirectPath redirects r to the escaped path, keeping the query.
+func redirectPath(w http.ResponseWriter, r *http.Request, escapedPath string) {
+	u := url.URL{RawQuery: r.URL.RawQuery}
+	if path, err := url.PathUnescape(escapedPath); err == nil {
+		u.Path, u.RawPath = path, escapedPath
 	} else {
-		// If no routes registered, return 404
-		http.NotFound(w, r)
+		u.Path = escapedPath
 	}
+	http.Redirect(w, r, u.String(), http.StatusTemporaryRedirect)
+}
+
+// serveDraining answers a request received during shutdown with a 503
+// *Error. Retry-After is the time left until the expected end of the
+// shutdown, so clients retry against the next instance.
+func serveDraining(c *Context) error {
+	remaining := time.Until(time.Unix(0, c.app.drainEnd.Load()))
+	seconds := max(int((remaining+time.Second-1)/time.Second), 1)
+
+	h := c.res.Header()
+	h.Set("Retry-After", strconv.Itoa(seconds))
+	h.Set("Connection", "close")
+	return NewError(http.StatusServiceUnavailable)
+}
+
+// serveUnmatched answers requests matching no route with the
+// OptionsHandler or MethodNotAllowedHandler if the path is registered for
+// other methods, and with the NotFoundHandler otherwise.
+func (app *App) serveUnmatched(w http.ResponseWriter, r *http.Request, host, path string) {
+	if allowed := app.allowed(host, path); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		if r.Method == http.MethodOptions {
+			app.options.serve(w, r)
+			return
+		}
+		app.methodNotAllowed.serve(w, r)
+		return
+	}
+	app.notFound.serve(w, r)
 }
 
 // Listen starts the HTTP server on the specified address.
 func (app *App) Listen(addr string) error {
 	app.server.Addr = addr
-	return app.server.ListenAndServe()
+	if addr == "" {
+		addr = ":http"
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if err := app.hooks.listen(ln.Addr().String()); err != nil {
+		ln.Close()
+		return err
+	}
+	if app.config.MaxConnsPerIP > 0 {
+		ln = newIPLimitListener(ln, app.config.MaxConnsPerIP)
+	}
+	return app.server.Serve(ln)
 }
 
-// Shutdown gracefully shuts down the server.
+// Shutdown gracefully shuts down the server, waiting without a deadline
+// for active connections to finish.
 func (app *App) Shutdown() error {
-	return app.server.Shutdown(nil)
+	return app.ShutdownWithContext(context.Background())
+}
+
+// ShutdownWithTimeout gracefully shuts down the server, giving active
+// connections at most d to finish.
+func (app *App) ShutdownWithTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return app.ShutdownWithContext(ctx)
+}
+
+// ShutdownWithContext gracefully shuts down the server: it stops accepting
+// connections, waits for active ones to finish until ctx is done, then runs
+// the OnShutdown hooks in registration order. During Config.DrainWindow and
+// while connections finish, requests get a 503. Long-lived streams registered
+// with Context.TrackStream are notified and get Config.StreamGracePeriod
+// to close.
+func (app *App) ShutdownWithContext(ctx context.Context) error {
+	// Requests are answered with 503 from now on.
+	end := time.Now().Add(app.config.DrainWindow)
+	if deadline, ok := ctx.Deadline(); ok && deadline.After(end) {
+		end = deadline
+	}
+	app.drainEnd.Store(end.UnixNano())
+
+	if app.config.DrainWindow > 0 {
+		timer := time.NewTimer(app.config.DrainWindow)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		app.drainStreams(ctx)
+		close(drained)
+	}()
+
+	err := app.server.Shutdown(ctx)
+	<-drained
+
+	hooks := app.allShutdownHooks()
+
+	errs := []error{err}
+	for _, hook := range hooks {
+		errs = append(errs, hook(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// drainStreams drains the streams of app and its mounted apps concurrently.
+func (app *App) drainStreams(ctx context.Context) {
+	app.mutex.Lock()
+	mounted := app.mounted
+	app.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sub := range mounted {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub.drainStreams(ctx)
+		}()
+	}
+	app.streams.drain(ctx, app.config.StreamGracePeriod)
+	wg.Wait()
+}
+
+// allShutdownHooks returns the hooks of the mounted apps, then those of app.
+func (app *App) allShutdownHooks() []func(ctx context.Context) error {
+	app.mutex.Lock()
+	mounted := app.mounted
+	app.mutex.Unlock()
+	hooks := app.hooks.shutdownHooks()
+
+	var all []func(ctx context.Context) error
+	for _, sub := range mounted {
+		all = append(all, sub.allShutdownHooks()...)
+	}
+	return append(all, hooks...)
+}
+
+// OnShutdown registers a hook run by ShutdownWithContext once connections
+// are drained. It is a shorthand for app.Hooks().OnShutdown(hook).
+func (app *App) OnShutdown(hook func(ctx context.Context) error) {
+	app.hooks.OnShutdown(hook)
 }
 
 // Group represents a route group with shared prefix and middleware.
 type Group struct {
 	app        *App
+	parent     *Group
+	host       *hostRouter
 	prefix     string
-	middleware []MiddlewareFunc
+	middleware []namedMiddleware
+
+	// errorHandler handles the errors of the group routes, if set.
+	errorHandler ErrorHandler
 }
 
 // Get registers a GET route in this group.
-func (g *Group) Get(path string, handler Handler, middleware ...MiddlewareFunc) {
-	g.addRoute("GET", path, handler, middleware...)
+func (g *Group) Get(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute("GET", path, handler, middleware...)
 }
 
 // Post registers a POST route in this group.
-func (g *Group) Post(path string, handler Handler, middleware ...MiddlewareFunc) {
-	g.addRoute("POST", path, handler, middleware...)
+func (g *Group) Post(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute("POST", path, handler, middleware...)
 }
 
 // Put registers a PUT route in this group.
-func (g *Group) Put(path string, handler Handler, middleware ...MiddlewareFunc) {
-	g.addRoute("PUT", path, handler, middleware...)
+func (g *Group) Put(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute("PUT", path, handler, middleware...)
 }
 
 // Delete registers a DELETE route in this group.
-func (g *Group) Delete(path string, handler Handler, middleware ...MiddlewareFunc) {
-	g.addRoute("DELETE", path, handler, middleware...)
+func (g *Group) Delete(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute("DELETE", path, handler, middleware...)
 }
 
 // Patch registers a PATCH route in this group.
-func (g *Group) Patch(path string, handler Handler, middleware ...MiddlewareFunc) {
-	g.addRoute("PATCH", path, handler, middleware...)
+func (g *Group) Patch(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute("PATCH", path, handler, middleware...)
 }
 
 // Head registers a HEAD route in this group.
-func (g *Group) Head(path string, handler Handler, middleware ...MiddlewareFunc) {
-	g.addRoute("HEAD", path, handler, middleware...)
+func (g *Group) Head(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute("HEAD", path, handler, middleware...)
 }
 
 // Options registers an OPTIONS route in this group.
-func (g *Group) Options(path string, handler Handler, middleware ...MiddlewareFunc) {
-	g.addRoute("OPTIONS", path, handler, middleware...)
+func (g *Group) Options(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute("OPTIONS", path, handler, middleware...)
+}
+
+// All registers a route answering every HTTP method in this group.
+func (g *Group) All(path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute("", path, handler, middleware...)
+}
+
+// Match registers a route answering the given HTTP methods in this group.
+func (g *Group) Match(methods []string, path string, handler Handler, middleware ...MiddlewareFunc) *Route {
+	return g.addRoute(joinMethods(methods), path, handler, middleware...)
 }
 
 // Use adds middleware to this group.
 func (g *Group) Use(middleware ...MiddlewareFunc) {
-	g.middleware = append(g.middleware, middleware...)
+	g.middleware = append(g.middleware, nameMiddleware(middleware)...)
 }
 
 // Group creates a sub-group with additional prefix and middleware.
 func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) *Group {
 	return &Group{
 		app:        g.app,
+		parent:     g,
+		host:       g.host,
 		prefix:     g.prefix + prefix,
-		middleware: append(g.middleware, middleware...),
+		middleware: slices.Concat(g.middleware, nameMiddleware(middleware)),
+	}
+}
+
+// Handler returns the group as a plain http.Handler, so a configured group can be
+// mounted into an existing http.ServeMux based application during migration.
+// It serves only the routes registered through the group and its sub-groups,
+// on the group host if it has one; other requests get the NotFoundHandler.
+func (g *Group) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, ok := g.app.admit(w, r)
+		if !ok {
+			return
+		}
+		m, ok := g.lookup(requestHost(r), r.Method, r.URL.EscapedPath())
+		if !ok {
+			g.app.notFound.serve(w, r)
+			return
+		}
+		m.serve(w, r)
+	})
+}
+
+// lookup returns the route of the group or its sub-groups serving method
+// for the escaped path on host.
+func (g *Group) lookup(host, method, escapedPath string) (routeMatch, bool) {
+	var m routeMatch
+	var ok bool
+	if g.host != nil {
+		m, ok = g.host.lookup(host, method, escapedPath)
+	} else {
+		m, ok = g.app.router.lookup(method, escapedPath)
+	}
+	if !ok || !m.route.within(g) {
+		return routeMatch{}, false
+	}
+	return m, true
+}
+
+// within reports whether r was registered through g or one of its
+// sub-groups.
+func (r *Route) within(g *Group) bool {
+	for group := r.group; group != nil; group = group.parent {
+		if group == g {
+			return true
+		}
 	}
+	return false
 }
 
 // addRoute adds a route to the group with the group's prefix and middleware.
-func (g *Group) addRoute(method, path string, handler Handler, middleware ...MiddlewareFunc) {
+func (g *Group) addRoute(method, path string, handler Handler, middleware ...MiddlewareFunc) *Route {
 	fullPath := g.prefix + path
 
 	// Combine group middleware with route-specific middleware
-	allMiddleware := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
-	allMiddleware = append(allMiddleware, g.middleware...)
-	allMiddleware = append(allMiddleware, middleware...)
+	allMiddleware := slices.Concat(g.middleware, nameMiddleware(middleware))
+
+	route := g.app.addHostRoute(g.host, method, fullPath, handler, allMiddleware)
+	route.prefix = g.prefix
+	route.group = g
+	return route
+}
 
-	g.app.addRoute(method, fullPath, handler, allMiddleware...)
+// ErrorHandler sets the handler for errors returned by the routes of the
+// group and its sub-groups, e.g. JSON errors for an API group and HTML
+// error pages elsewhere. The nearest group with a handler wins, falling
+// back to Config.ErrorHandler. Unmatched requests below the group prefix
+// are handled by it too.
+func (g *Group) ErrorHandler(handler ErrorHandler) {
+	g.app.mutex.Lock()
+	defer g.app.mutex.Unlock()
+
+	if g.errorHandler == nil {
+		g.app.errorGroups = append(g.app.errorGroups, g)
+	}
+	g.errorHandler = handler
+}
+
+// errorHandler returns the error handler for the request of c: the one of
+// the nearest group of its route, or for unmatched requests of the group
+// with the longest prefix covering the path, else Config.ErrorHandler.
+func (app *App) errorHandler(c *Context) ErrorHandler {
+	route := c.route
+	if route != nil && route != app.notFound && route != app.methodNotAllowed && route != app.options {
+		for g := route.group; g != nil; g = g.parent {
+			if g.errorHandler != nil {
+				return g.errorHandler
+			}
+		}
+		return app.config.ErrorHandler
+	}
+
+	handler, longest := app.config.ErrorHandler, -1
+	for _, g := range app.errorGroups {
+		if g.host != nil {
+			if _, ok := g.host.match(requestHost(c.req)); !ok {
+				continue
+			}
+		}
+		if len(g.prefix) > longest && hasPathPrefix(c.req.URL.Path, g.prefix) {
+			handler, longest = g.errorHandler, len(g.prefix)
+		}
+	}
+	return handler
+}
+
+// hasPathPrefix reports whether path equals prefix or lies below it,
+// so "/api" matches "/api" and "/api/users" but not "/apix".
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/'
 }
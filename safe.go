@@ -0,0 +1,57 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is the error produced when a handler wrapped by WrapSafe panics.
+// It carries the recovered value and the stack of the panicking goroutine.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the formatted stack trace captured at the time of the panic.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// WrapSafe returns a Handler that recovers panics raised by h and returns
+// them as a *PanicError, so the chain keeps producing an error instead of
+// crashing the connection.
+// http.ErrAbortHandler is re-panicked to keep its net/http meaning.
+func WrapSafe(h Handler) Handler {
+	return HandlerFunc(func(ctx *Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				if e, ok := r.(error); ok && errors.Is(e, http.ErrAbortHandler) {
+					panic(r)
+				}
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return h.Handle(ctx)
+	})
+}
+
+// SafeMiddleware wraps m so that panics raised by the middleware, or by any
+// handler further down the chain, are returned as a *PanicError.
+func SafeMiddleware(m MiddlewareFunc) MiddlewareFunc {
+	return func(next Handler) Handler {
+		return WrapSafe(m(next))
+	}
+}
@@ -0,0 +1,122 @@
+package mux
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// SendFileOption configures SendFile.
+type SendFileOption func(*sendFileConfig)
+
+// sendFileConfig holds the settings of a SendFile call.
+type sendFileConfig struct {
+	// attachment is the download file name, empty for inline files.
+	attachment string
+
+	// maxAge is sent as Cache-Control max-age when positive.
+	maxAge time.Duration
+
+	// root confines the path to a directory when set.
+	root string
+}
+
+// AsAttachment makes the client download the file as filename.
+func AsAttachment(filename string) SendFileOption {
+	return func(cfg *sendFileConfig) { cfg.attachment = filename }
+}
+
+// FileMaxAge sets the Cache-Control max-age sent with the file.
+func FileMaxAge(d time.Duration) SendFileOption {
+	return func(cfg *sendFileConfig) { cfg.maxAge = d }
+}
+
+// FileRoot confines the path passed to SendFile to the directory dir: the
+// path is resolved relative to dir, and the file is opened through an
+// os.Root so neither ".." segments nor symbolic links can reach outside of
+// it. Use it whenever the path is derived from the request.
+func FileRoot(dir string) SendFileOption {
+	return func(cfg *sendFileConfig) { cfg.root = dir }
+}
+
+// SendFile writes the file at path. The content type is derived from the
+// extension, and byte ranges and conditional requests are answered.
+// A missing file produces a 404 *Error.
+func (c *Context) SendFile(path string, opts ...SendFileOption) error {
+	var cfg sendFileConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	f, err := openFile(path, cfg.root)
+	if err != nil {
+		return fileError(err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return fileError(err)
+	}
+	if stat.IsDir() {
+		return NewError(http.StatusNotFound)
+	}
+
+	if cfg.attachment != "" {
+		c.res.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": cfg.attachment,
+		}))
+	}
+	if cfg.maxAge > 0 {
+		c.res.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(cfg.maxAge.Seconds())))
+	}
+	http.ServeContent(c.res, c.req, stat.Name(), stat.ModTime(), f)
+	return nil
+}
+
+// openFile opens the file at path, relative to root when root is set.
+func openFile(path, root string) (*os.File, error) {
+	if root == "" {
+		return os.Open(path)
+	}
+
+	name, ok := cleanFileName(filepath.ToSlash(path))
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	fsys, err := os.OpenRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	defer fsys.Close()
+	return fsys.Open(name)
+}
+
+// Download writes the file at path as an attachment named filename.
+// An empty filename uses the base name of path.
+func (c *Context) Download(path, filename string) error {
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
+	return c.SendFile(path, AsAttachment(filename))
+}
+
+// Stream copies r to the response with the given status and content type.
+// The length is unknown upfront, so the body is sent with chunked transfer
+// encoding. If r is an io.Closer it is closed afterwards. Text content
+// types without a charset parameter get Config.DefaultCharset.
+func (c *Context) Stream(status int, contentType string, r io.Reader) error {
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	c.res.Header().Set(HeaderContentType, withCharset(contentType, c.app.config.DefaultCharset))
+	c.res.WriteHeader(status)
+	_, err := io.Copy(c.res, r)
+	return err
+}
@@ -0,0 +1,204 @@
+package mux
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MIMETextEventStream is the content type of Server-Sent Events streams.
+const MIMETextEventStream = "text/event-stream"
+
+// ErrStreamClosed is returned when writing to a closed or disconnected stream.
+var ErrStreamClosed = errors.New("mux: stream closed")
+
+// errSSELineBreak is returned for event IDs and names with line breaks,
+// which would end the field early and inject others.
+var errSSELineBreak = errors.New("mux: SSE event ID and name must not contain line breaks")
+
+// sseLineBreaks normalizes the line breaks of event data, CRLF, CR and LF,
+// to LF.
+var sseLineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// SSEWriter writes Server-Sent Events to the client. It is created by
+// Context.SSE and is safe for concurrent use.
+type SSEWriter struct {
+	// mutex serializes writes from Send and the heartbeat.
+	mutex sync.Mutex
+
+	res    http.ResponseWriter
+	rc     *http.ResponseController
+	done   <-chan struct{}
+	stop   chan struct{}
+	closed bool
+
+	// untrack ends the stream registration for graceful shutdown.
+	untrack func()
+}
+
+// SSE starts a Server-Sent Events stream: it sends the event stream headers,
+// lifts the server write timeout for the response and starts a heartbeat
+// comment every Config.SSEHeartbeat to keep proxies from closing the
+// connection. The stream ends when the client disconnects, the application
+// shuts down or the writer is closed; it is closed automatically when the
+// handler returns.
+func (c *Context) SSE() (*SSEWriter, error) {
+	rc := http.NewResponseController(c.res)
+
+	// Streams outlive the server write timeout.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+
+	h := c.res.Header()
+	h.Set(HeaderContentType, MIMETextEventStream)
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	c.res.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, err
+	}
+
+	ctx, done := c.TrackStream(nil)
+	s := &SSEWriter{
+		res:     c.res,
+		rc:      rc,
+		done:    ctx.Done(),
+		stop:    make(chan struct{}),
+		untrack: done,
+	}
+	c.onReturn(func() { s.Close() })
+
+	if interval := c.app.config.SSEHeartbeat; interval > 0 {
+		go s.heartbeat(interval)
+	}
+	return s, nil
+}
+
+// Done is closed when the client disconnects or the application starts
+// shutting down. The handler should return promptly once it is closed.
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.done
+}
+
+// Send writes an event named event with data and flushes it.
+// data is sent as is when it is a string or []byte, and as JSON otherwise.
+// An empty event name sends an unnamed "message" event.
+func (s *SSEWriter) Send(event string, data any) error {
+	return s.SendEvent(SSEEvent{Event: event, Data: data})
+}
+
+// SSEEvent is a Server-Sent Event with all optional fields.
+type SSEEvent struct {
+	// ID sets the last event ID the client reports when reconnecting.
+	// It must not contain CR or LF.
+	ID string
+
+	// Event is the event name. It must not contain CR or LF.
+	Event string
+
+	// Data is the payload, sent as is when it is a string or []byte,
+	// and as JSON otherwise.
+	Data any
+
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// SendEvent writes e and flushes it. An ID or Event with a line break is
+// rejected.
+func (s *SSEWriter) SendEvent(e SSEEvent) error {
+	if strings.ContainsAny(e.ID, "\r\n") || strings.ContainsAny(e.Event, "\r\n") {
+		return errSSELineBreak
+	}
+
+	var data string
+	switch v := e.Data.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+
+	var sb strings.Builder
+	if e.ID != "" {
+		sb.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		sb.WriteString("event: " + e.Event + "\n")
+	}
+	if e.Retry > 0 {
+		sb.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	// Multi-line data is sent as one data field per line, split at any of
+	// the line breaks clients recognize.
+	for line := range strings.SplitSeq(sseLineBreaks.Replace(data), "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteByte('\n')
+
+	return s.write(sb.String())
+}
+
+// Close stops the heartbeat. Further writes return ErrStreamClosed.
+func (s *SSEWriter) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+		s.untrack()
+	}
+	return nil
+}
+
+// write sends msg and flushes it.
+func (s *SSEWriter) write(msg string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrStreamClosed
+	}
+	select {
+	case <-s.done:
+		return ErrStreamClosed
+	default:
+	}
+
+	if _, err := io.WriteString(s.res, msg); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// heartbeat sends a comment line every interval until the stream ends.
+func (s *SSEWriter) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if s.write(": ping\n\n") != nil {
+				return
+			}
+		case <-s.stop:
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
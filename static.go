@@ -0,0 +1,227 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// StaticOption configures Static.
+type StaticOption func(*staticConfig)
+
+// staticConfig holds the settings of a static file route.
+type staticConfig struct {
+	// index lists the file names served for a directory.
+	index []string
+
+	// browse enables directory listings.
+	browse bool
+
+	// maxAge is sent as Cache-Control max-age when positive.
+	maxAge time.Duration
+
+	// spa serves the root index file for paths that do not exist.
+	spa bool
+}
+
+// WithIndex sets the file names served for a directory request.
+// Default: "index.html".
+func WithIndex(names ...string) StaticOption {
+	return func(cfg *staticConfig) { cfg.index = names }
+}
+
+// WithBrowse enables directory listings for directories without an index file.
+func WithBrowse() StaticOption {
+	return func(cfg *staticConfig) { cfg.browse = true }
+}
+
+// WithMaxAge sets the Cache-Control max-age sent with files.
+func WithMaxAge(d time.Duration) StaticOption {
+	return func(cfg *staticConfig) { cfg.maxAge = d }
+}
+
+// WithSPA serves the root index file for paths that do not exist, so a
+// single page application can handle its own client-side routes. It
+// requires an index file name; Static panics if WithIndex set none.
+func WithSPA() StaticOption {
+	return func(cfg *staticConfig) { cfg.spa = true }
+}
+
+// Static serves the files below root under the URL prefix.
+// Byte ranges and conditional requests are supported for files.
+func (app *App) Static(prefix, root string, opts ...StaticOption) *Route {
+	return app.Get(staticPattern(prefix), newStaticHandler(root, opts...))
+}
+
+// Static serves the files below root under the group prefix plus prefix.
+func (g *Group) Static(prefix, root string, opts ...StaticOption) *Route {
+	return g.Get(staticPattern(prefix), newStaticHandler(root, opts...))
+}
+
+// staticPattern returns the route pattern matching everything below prefix.
+func staticPattern(prefix string) string {
+	return strings.TrimSuffix(prefix, "/") + "/{path...}"
+}
+
+// newStaticHandler returns the handler serving files from root.
+// Files are opened through an os.Root, so neither the request path nor
+// symbolic links can reach outside of root.
+func newStaticHandler(root string, opts ...StaticOption) Handler {
+	cfg := staticConfig{index: []string{"index.html"}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.spa && len(cfg.index) == 0 {
+		panic("mux: static: WithSPA requires an index file name")
+	}
+
+	return HandlerFunc(func(c *Context) error {
+		name, ok := cleanFileName(c.Param("path"))
+		if !ok {
+			return NewError(http.StatusNotFound)
+		}
+
+		fsys, err := os.OpenRoot(root)
+		if err != nil {
+			return fileError(err)
+		}
+		defer fsys.Close()
+
+		f, err := fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) && cfg.spa {
+			name = cfg.index[0]
+			f, err = fsys.Open(name)
+		}
+		if err != nil {
+			return fileError(err)
+		}
+		defer f.Close()
+
+		stat, err := f.Stat()
+		if err != nil {
+			return fileError(err)
+		}
+
+		if stat.IsDir() {
+			// Directories are addressed with a trailing slash so relative links work.
+			if !strings.HasSuffix(c.req.URL.Path, "/") {
+				u := url.URL{Path: c.req.URL.Path + "/", RawQuery: c.req.URL.RawQuery}
+				http.Redirect(c.res, c.req, u.String(), http.StatusMovedPermanently)
+				return nil
+			}
+			for _, index := range cfg.index {
+				indexFile, err := fsys.Open(path.Join(name, index))
+				if err != nil {
+					continue
+				}
+				defer indexFile.Close()
+				if indexStat, err := indexFile.Stat(); err == nil && !indexStat.IsDir() {
+					f, stat = indexFile, indexStat
+					break
+				}
+			}
+			if stat.IsDir() {
+				if !cfg.browse {
+					return NewError(http.StatusForbidden)
+				}
+				return dirList(c, f)
+			}
+		}
+
+		if cfg.maxAge > 0 {
+			c.res.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(cfg.maxAge.Seconds())))
+		}
+		http.ServeContent(c.res, c.req, stat.Name(), stat.ModTime(), f)
+		return nil
+	})
+}
+
+// cleanFileName turns the decoded request path p into a name relative to
+// a root, "." for the root itself. It rejects rather than cleans anything
+// that could address a file outside of the root: ".." segments, NUL bytes,
+// backslashes, and encoded forms of those that would survive a second
+// decoding, such as %2e%2e%2f.
+func cleanFileName(p string) (string, bool) {
+	if !fileNameSafe(p) {
+		return "", false
+	}
+	if strings.Contains(p, "%") {
+		decoded, err := url.PathUnescape(p)
+		if err != nil || !fileNameSafe(decoded) {
+			return "", false
+		}
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, true
+}
+
+// fileNameSafe reports whether p has no ".." segment, NUL byte or backslash.
+func fileNameSafe(p string) bool {
+	if strings.ContainsAny(p, "\x00\\") {
+		return false
+	}
+	for segment := range strings.SplitSeq(p, "/") {
+		if segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// fileError maps a file system error to a 404 or 403 *Error.
+// Errors other than system errors, such as os.Root refusing a path that
+// escapes it, are reported as 404 too.
+func fileError(err error) error {
+	var errno syscall.Errno
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return wrapError(http.StatusNotFound, err)
+	case errors.Is(err, fs.ErrPermission):
+		return wrapError(http.StatusForbidden, err)
+	case !errors.As(err, &errno):
+		return wrapError(http.StatusNotFound, err)
+	}
+	return err
+}
+
+// dirList writes an HTML listing of the directory f.
+func dirList(c *Context, f http.File) error {
+	entries, err := f.Readdir(-1)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	buf := dirListBuffers.acquire()
+	defer dirListBuffers.release(buf)
+
+	title := html.EscapeString(c.req.URL.Path)
+	fmt.Fprintf(buf, "<!doctype html>\n<title>%s</title>\n<h1>%s</h1>\n<ul>\n", title, title)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		link := url.URL{Path: name}
+		fmt.Fprintf(buf, "<li><a href=\"%s\">%s</a></li>\n", link.String(), html.EscapeString(name))
+	}
+	buf.WriteString("</ul>\n")
+
+	c.res.Header().Set(HeaderContentType, "text/html; charset=utf-8")
+	_, err = c.res.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,136 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// secret is the content of the file outside of the served root.
+const secret = "TOPSECRET"
+
+// newFileTree creates a served root with a public file, and a secret file
+// and directory next to it that symbolic links inside the root point to.
+func newFileTree(t *testing.T) (dir, root string) {
+	t.Helper()
+	dir = t.TempDir()
+	root = filepath.Join(dir, "public")
+	outside := filepath.Join(dir, "private")
+	for _, d := range []string{root, outside} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		filepath.Join(root, "ok.txt"):       "public",
+		filepath.Join(dir, "secret.txt"):    secret,
+		filepath.Join(outside, "inner.txt"): secret,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"link.txt": filepath.Join(dir, "secret.txt"),
+		"rel.txt":  "../secret.txt",
+		"dirlink":  outside,
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+	return dir, root
+}
+
+// bypassTargets are request targets below /s/ trying to reach outside of
+// the served root.
+var bypassTargets = []struct {
+	name, target string
+}{
+	{"dot dot", "/s/../secret.txt"},
+	{"nested dot dot", "/s/a/../../secret.txt"},
+	{"encoded dot dot", "/s/%2e%2e/secret.txt"},
+	{"mixed case encoded dot dot", "/s/%2E%2e/secret.txt"},
+	{"encoded slash", "/s/..%2fsecret.txt"},
+	{"encoded slash prefix", "/s/%2f..%2fsecret.txt"},
+	{"double encoded dot dot", "/s/%252e%252e/secret.txt"},
+	{"double encoded slash", "/s/..%252fsecret.txt"},
+	{"backslash", `/s/..\secret.txt`},
+	{"encoded backslash", "/s/..%5csecret.txt"},
+	{"double encoded backslash", "/s/..%255csecret.txt"},
+	{"NUL", "/s/ok.txt%00"},
+	{"double encoded NUL", "/s/ok.txt%2500"},
+	{"absolute symlink", "/s/link.txt"},
+	{"relative symlink", "/s/rel.txt"},
+	{"directory symlink", "/s/dirlink/inner.txt"},
+}
+
+func TestStaticBypass(t *testing.T) {
+	_, root := newFileTree(t)
+	app := New(Config{})
+	app.Static("/s", root, WithBrowse())
+
+	for _, tc := range bypassTargets {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if w.Code == http.StatusOK || strings.Contains(w.Body.String(), secret) {
+				t.Fatalf("GET %s: status %d, body %q", tc.target, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/s/ok.txt", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "public" {
+		t.Fatalf("GET /s/ok.txt: status %d, body %q", w.Code, w.Body.String())
+	}
+}
+
+func TestSendFileRootBypass(t *testing.T) {
+	dir, root := newFileTree(t)
+	app := New(Config{})
+	app.Get("/f", HandlerFunc(func(c *Context) error {
+		return c.SendFile(c.Query("name"), FileRoot(root))
+	}))
+
+	names := []string{
+		"../secret.txt",
+		"a/../../secret.txt",
+		"/../secret.txt",
+		"%2e%2e/secret.txt",
+		"..%2fsecret.txt",
+		"%2e%2e%2fsecret.txt",
+		`..\secret.txt`,
+		"..%5csecret.txt",
+		"ok.txt\x00",
+		"ok.txt%00",
+		filepath.Join(dir, "secret.txt"),
+		"link.txt",
+		"rel.txt",
+		"dirlink/inner.txt",
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			target := "/f?name=" + url.QueryEscape(name)
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			if w.Code == http.StatusOK || strings.Contains(w.Body.String(), secret) {
+				t.Fatalf("SendFile(%q): status %d, body %q", name, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/f?name=ok.txt", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "public" {
+		t.Fatalf("SendFile(ok.txt): status %d, body %q", w.Code, w.Body.String())
+	}
+}
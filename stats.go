@@ -0,0 +1,262 @@
+package mux
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a runtime snapshot of the application.
+// It is cheap enough to be polled by monitoring pages and health checks.
+type Stats struct {
+	// Requests holds the request counters.
+	Requests RequestStats `json:"requests"`
+
+	// Latency holds the handler latency percentiles.
+	Latency LatencyStats `json:"latency"`
+
+	// Connections holds the connection gauges and counters.
+	Connections ConnStats `json:"connections"`
+
+	// BufferPool holds the response buffer pool counters.
+	BufferPool BufferPoolStats `json:"buffer_pool"`
+
+	// Binding holds the request body decoding counters by content type.
+	Binding map[string]BindStats `json:"binding"`
+}
+
+// RequestStats holds counters of requests dispatched to routes.
+type RequestStats struct {
+	// Served is the total number of requests served.
+	Served uint64 `json:"served"`
+
+	// Errors is the number of requests whose handler returned an error.
+	Errors uint64 `json:"errors"`
+
+	// ClientErrors is the number of responses with a 4xx status.
+	ClientErrors uint64 `json:"client_errors"`
+
+	// ServerErrors is the number of responses with a 5xx status.
+	ServerErrors uint64 `json:"server_errors"`
+}
+
+// LatencyStats holds handler latency percentiles estimated with a t-digest.
+type LatencyStats struct {
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+}
+
+// ConnStats holds connection gauges and counters collected through
+// http.Server.ConnState.
+type ConnStats struct {
+	// Accepted is the total number of accepted connections.
+	Accepted uint64 `json:"accepted"`
+
+	// Open is the number of connections currently open.
+	Open int64 `json:"open"`
+
+	// Active is the number of open connections currently serving a request.
+	Active int64 `json:"active"`
+
+	// Idle is the number of open keep-alive connections waiting for a request.
+	Idle int64 `json:"idle"`
+
+	// Hijacked is the total number of connections taken over by handlers.
+	Hijacked uint64 `json:"hijacked"`
+
+	// Closed is the total number of connections closed by the server or client.
+	Closed uint64 `json:"closed"`
+}
+
+// Stats returns a runtime snapshot of the application.
+func (app *App) Stats() Stats {
+	return Stats{
+		Requests:    app.requests.stats(),
+		Latency:     app.requests.latencies(),
+		Connections: app.conns.stats(),
+		BufferPool:  buffers.stats(),
+		Binding:     app.binds.snapshot(),
+	}
+}
+
+// requestTracker maintains RequestStats and LatencyStats. Requests only
+// touch atomic counters and one of the latency shards; the t-digest is
+// fed a batch at a time.
+type requestTracker struct {
+	served       atomic.Uint64
+	errors       atomic.Uint64
+	clientErrors atomic.Uint64
+	serverErrors atomic.Uint64
+
+	// shards buffer the latency samples not folded into the digest yet.
+	shards [latencyShards]latencyShard
+
+	// mutex protects latency.
+	mutex sync.Mutex
+
+	// latency holds handler durations in nanoseconds.
+	latency *tdigest
+}
+
+// latencyShards is the number of latency sample buffers requests are
+// spread over, and latencyBatch the number of samples a buffer holds
+// before it is folded into the digest.
+const (
+	latencyShards = 16
+	latencyBatch  = 256
+)
+
+// latencyShard is a buffer of latency samples.
+type latencyShard struct {
+	mutex   sync.Mutex
+	samples []float64
+
+	// The padding keeps shards on separate cache lines.
+	_ [32]byte
+}
+
+// record accounts for a served request.
+func (t *requestTracker) record(status int, err error, d time.Duration) {
+	n := t.served.Add(1)
+	if err != nil {
+		t.errors.Add(1)
+	}
+	switch {
+	case status >= 500:
+		t.serverErrors.Add(1)
+	case status >= 400:
+		t.clientErrors.Add(1)
+	}
+
+	s := &t.shards[n%latencyShards]
+	s.mutex.Lock()
+	if s.samples == nil {
+		s.samples = make([]float64, 0, latencyBatch)
+	}
+	s.samples = append(s.samples, float64(d))
+	var batch []float64
+	if len(s.samples) == latencyBatch {
+		batch, s.samples = s.samples, nil
+	}
+	s.mutex.Unlock()
+
+	if batch != nil {
+		t.fold(batch)
+	}
+}
+
+// fold adds samples to the digest.
+func (t *requestTracker) fold(samples []float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.latency == nil {
+		t.latency = newTDigest(100)
+	}
+	for _, x := range samples {
+		t.latency.add(x)
+	}
+}
+
+// stats returns the current request counters.
+func (t *requestTracker) stats() RequestStats {
+	return RequestStats{
+		Served:       t.served.Load(),
+		Errors:       t.errors.Load(),
+		ClientErrors: t.clientErrors.Load(),
+		ServerErrors: t.serverErrors.Load(),
+	}
+}
+
+// latencies folds the buffered samples and returns the current latency
+// percentiles.
+func (t *requestTracker) latencies() LatencyStats {
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mutex.Lock()
+		batch := s.samples
+		s.samples = nil
+		s.mutex.Unlock()
+		if len(batch) > 0 {
+			t.fold(batch)
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.latency == nil {
+		return LatencyStats{}
+	}
+	return LatencyStats{
+		P50: time.Duration(t.latency.quantile(0.50)),
+		P95: time.Duration(t.latency.quantile(0.95)),
+		P99: time.Duration(t.latency.quantile(0.99)),
+	}
+}
+
+// connTracker maintains ConnStats from http.Server.ConnState transitions.
+type connTracker struct {
+	// mutex protects all fields below.
+	mutex sync.Mutex
+
+	// states holds the last known state of every open connection.
+	states map[net.Conn]http.ConnState
+
+	// counts is the running set of gauges and counters.
+	counts ConnStats
+}
+
+// track is installed as http.Server.ConnState.
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.states == nil {
+		t.states = make(map[net.Conn]http.ConnState)
+	}
+
+	// Leave the previous state first.
+	prev, known := t.states[conn]
+	if known {
+		switch prev {
+		case http.StateActive:
+			t.counts.Active--
+		case http.StateIdle:
+			t.counts.Idle--
+		}
+	}
+
+	switch state {
+	case http.StateNew:
+		t.counts.Accepted++
+		t.counts.Open++
+	case http.StateActive:
+		t.counts.Active++
+	case http.StateIdle:
+		t.counts.Idle++
+	case http.StateHijacked:
+		t.counts.Hijacked++
+	case http.StateClosed:
+		t.counts.Closed++
+	}
+
+	if state == http.StateHijacked || state == http.StateClosed {
+		if known {
+			t.counts.Open--
+		}
+		delete(t.states, conn)
+		return
+	}
+	t.states[conn] = state
+}
+
+// stats returns a copy of the current counts.
+func (t *connTracker) stats() ConnStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.counts
+}
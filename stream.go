@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrServerShutdown is the cancellation cause of stream contexts when the
+// application shuts down.
+var ErrServerShutdown = errors.New("mux: server shutting down")
+
+// streamTracker coordinates long-lived connections, such as WebSocket and
+// SSE streams, with shutdown.
+type streamTracker struct {
+	mutex    sync.Mutex
+	draining bool
+	nextID   uint64
+	active   map[uint64]*stream
+}
+
+// stream is a tracked long-lived connection.
+type stream struct {
+	// cancel cancels the stream context.
+	cancel context.CancelCauseFunc
+
+	// forceClose severs the connection once the grace period is over.
+	forceClose func()
+}
+
+// TrackStream registers the request as a long-lived stream, such as a
+// WebSocket or SSE connection. The returned context is canceled with
+// ErrServerShutdown when the application starts shutting down, so the
+// handler can say goodbye and return. Streams still open when
+// Config.StreamGracePeriod ends are severed with forceClose, if not nil.
+// done must be called when the stream ends; it cancels the context.
+func (c *Context) TrackStream(forceClose func()) (ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancelCause(c.req.Context())
+	t := &c.app.streams
+
+	t.mutex.Lock()
+	if t.active == nil {
+		t.active = make(map[uint64]*stream)
+	}
+	id := t.nextID
+	t.nextID++
+	t.active[id] = &stream{cancel: cancel, forceClose: forceClose}
+	draining := t.draining
+	t.mutex.Unlock()
+
+	if draining {
+		cancel(ErrServerShutdown)
+	}
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			t.mutex.Lock()
+			delete(t.active, id)
+			t.mutex.Unlock()
+			cancel(context.Canceled)
+		})
+	}
+}
+
+// drain notifies the active streams of the shutdown, waits up to grace or
+// until ctx is done for them to end, then severs the remaining ones.
+func (t *streamTracker) drain(ctx context.Context, grace time.Duration) {
+	t.mutex.Lock()
+	t.draining = true
+	for _, s := range t.active {
+		s.cancel(ErrServerShutdown)
+	}
+	t.mutex.Unlock()
+
+	// Poll like http.Server.Shutdown does, streams end at their own pace.
+	deadline := time.NewTimer(grace)
+	defer deadline.Stop()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !t.idle() {
+		select {
+		case <-ticker.C:
+			continue
+		case <-deadline.C:
+		case <-ctx.Done():
+		}
+		break
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, s := range t.active {
+		if s.forceClose != nil {
+			s.forceClose()
+		}
+	}
+}
+
+// idle reports whether no stream is active.
+func (t *streamTracker) idle() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.active) == 0
+}
@@ -0,0 +1,103 @@
+package mux
+
+import "sort"
+
+// centroid is a cluster of samples in a tdigest.
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// tdigest is a merging t-digest estimating quantiles of a stream of samples
+// in bounded memory, accurate at the tails where latency percentiles live.
+// It is not safe for concurrent use.
+type tdigest struct {
+	// compression bounds the number of centroids kept.
+	compression float64
+
+	// centroids holds the merged clusters sorted by mean.
+	centroids []centroid
+
+	// buffer holds samples not merged yet.
+	buffer []centroid
+
+	// count is the total weight of the merged centroids.
+	count float64
+}
+
+// newTDigest returns an empty tdigest with the given compression.
+func newTDigest(compression float64) *tdigest {
+	return &tdigest{
+		compression: compression,
+		buffer:      make([]centroid, 0, int(compression)*5),
+	}
+}
+
+// add records a sample.
+func (t *tdigest) add(x float64) {
+	t.buffer = append(t.buffer, centroid{mean: x, weight: 1})
+	if len(t.buffer) == cap(t.buffer) {
+		t.merge()
+	}
+}
+
+// merge folds buffered samples into the centroids.
+func (t *tdigest) merge() {
+	if len(t.buffer) == 0 {
+		return
+	}
+
+	all := make([]centroid, 0, len(t.centroids)+len(t.buffer))
+	all = append(all, t.centroids...)
+	all = append(all, t.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	total := t.count + float64(len(t.buffer))
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	current := all[0]
+	soFar := 0.0
+	for _, c := range all[1:] {
+		// The size limit shrinks towards the tails, keeping them precise.
+		q := (soFar + current.weight + c.weight/2) / total
+		limit := 4 * total * q * (1 - q) / t.compression
+		if current.weight+c.weight <= limit {
+			current.weight += c.weight
+			current.mean += (c.mean - current.mean) * c.weight / current.weight
+			continue
+		}
+		merged = append(merged, current)
+		soFar += current.weight
+		current = c
+	}
+	merged = append(merged, current)
+
+	t.centroids = merged
+	t.count = total
+	t.buffer = t.buffer[:0]
+}
+
+// quantile returns the estimated value at quantile q in [0, 1].
+// It returns 0 when no samples were recorded.
+func (t *tdigest) quantile(q float64) float64 {
+	t.merge()
+	if len(t.centroids) == 0 {
+		return 0
+	}
+
+	target := q * t.count
+	cumulative := 0.0
+	prevCenter, prevMean := 0.0, t.centroids[0].mean
+	for i, c := range t.centroids {
+		center := cumulative + c.weight/2
+		if target < center {
+			if i == 0 {
+				return c.mean
+			}
+			// Interpolate between neighbouring centroid centers.
+			return prevMean + (c.mean-prevMean)*(target-prevCenter)/(center-prevCenter)
+		}
+		prevCenter, prevMean = center, c.mean
+		cumulative += c.weight
+	}
+	return t.centroids[len(t.centroids)-1].mean
+}
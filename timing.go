@@ -0,0 +1,40 @@
+package mux
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderServerTiming is the response header carrying Server-Timing metrics.
+const HeaderServerTiming = "Server-Timing"
+
+// AddServerTiming adds a Server-Timing metric, e.g. a database query or a
+// template render, so browsers and APMs can break down the request.
+// desc is optional. Metrics added after the response header was sent are
+// dropped.
+func (c *Context) AddServerTiming(name string, dur time.Duration, desc string) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteString(";dur=")
+	sb.WriteString(strconv.FormatFloat(float64(dur.Microseconds())/1000, 'f', -1, 64))
+	if desc != "" {
+		sb.WriteString(`;desc="`)
+		sb.WriteString(quoteEscaper.Replace(desc))
+		sb.WriteByte('"')
+	}
+	c.res.Header().Add(HeaderServerTiming, sb.String())
+}
+
+// Timing starts timing the metric name and returns the function stopping
+// it, which adds the Server-Timing metric:
+//
+//	stop := c.Timing("db")
+//	rows, err := db.Query(...)
+//	stop()
+func (c *Context) Timing(name string) func() {
+	start := time.Now()
+	return func() {
+		c.AddServerTiming(name, time.Since(start), "")
+	}
+}
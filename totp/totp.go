@@ -0,0 +1,172 @@
+// Package totp implements time-based one-time passwords (RFC 6238) for
+// enrolling and verifying authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config defines the TOTP parameters. They must match what the
+// authenticator app was provisioned with.
+type Config struct {
+	// Digits is the number of digits of a code, at most 9.
+	//
+	// Default: 6
+	Digits int
+
+	// Period is the time step a code is valid for, a whole number of
+	// seconds.
+	//
+	// Default: 30s
+	Period time.Duration
+
+	// Skew is the number of periods before and after the current one that
+	// are also accepted, tolerating clock drift between server and device.
+	// A negative value accepts the current period only.
+	//
+	// Default: 1
+	Skew int
+}
+
+// ConfigDefault is the default config, understood by common authenticator apps.
+var ConfigDefault = Config{
+	Digits: 6,
+	Period: 30 * time.Second,
+	Skew:   1,
+}
+
+// encoding is the unpadded base32 alphabet authenticator apps expect.
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random 160-bit secret, base32 encoded.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI returns the otpauth:// URI for secret, usually rendered as
+// a QR code scanned by the authenticator app during enrollment.
+func ProvisioningURI(secret, issuer, account string, config ...Config) string {
+	cfg := configDefault(config...)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(cfg.Digits))
+	query.Set("period", fmt.Sprint(int(cfg.Period.Seconds())))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// Code returns the code for secret at time t.
+func Code(secret string, t time.Time, config ...Config) (string, error) {
+	cfg := configDefault(config...)
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return code(key, counter(t, cfg.Period), cfg.Digits), nil
+}
+
+// Verify reports whether code is valid for secret at time t, accepting the
+// periods within the configured skew.
+func Verify(secret, code string, t time.Time, config ...Config) bool {
+	cfg := configDefault(config...)
+
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != cfg.Digits {
+		return false
+	}
+
+	current := counter(t, cfg.Period)
+	valid := 0
+	for i := -cfg.Skew; i <= cfg.Skew; i++ {
+		// Check every window in constant time to avoid leaking which one matched.
+		valid |= subtle.ConstantTimeCompare([]byte(codeAt(key, current, i, cfg.Digits)), []byte(code))
+	}
+	return valid == 1
+}
+
+// codeAt returns the code offset periods away from counter c.
+func codeAt(key []byte, c uint64, offset, digits int) string {
+	return code(key, uint64(int64(c)+int64(offset)), digits)
+}
+
+// code computes the HOTP value (RFC 4226) of key for counter c.
+func code(key []byte, c uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], c)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// counter returns the time step of t.
+func counter(t time.Time, period time.Duration) uint64 {
+	return uint64(t.Unix() / int64(period.Seconds()))
+}
+
+// decodeSecret decodes a base32 secret, tolerating lower case and spaces.
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return encoding.DecodeString(strings.TrimRight(secret, "="))
+}
+
+// configDefault returns the first config with unset fields filled from
+// ConfigDefault. It panics on a Digits or Period the algorithm cannot use.
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Digits <= 0 {
+		cfg.Digits = ConfigDefault.Digits
+	}
+	if cfg.Period <= 0 {
+		cfg.Period = ConfigDefault.Period
+	}
+	if cfg.Skew == 0 {
+		cfg.Skew = ConfigDefault.Skew
+	}
+	if cfg.Skew < 0 {
+		cfg.Skew = 0
+	}
+	if cfg.Digits > 9 {
+		panic(fmt.Sprintf("totp: Digits must be at most 9, got %d", cfg.Digits))
+	}
+	if cfg.Period < time.Second || cfg.Period%time.Second != 0 {
+		panic(fmt.Sprintf("totp: Period must be a whole number of seconds, got %s", cfg.Period))
+	}
+	return cfg
+}
@@ -0,0 +1,68 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Warn adds a Warning header (RFC 7234) with code, e.g. 299 for a
+// persistent miscellaneous warning, and text, and records text as a
+// warning of the request, see AddWarning.
+func (c *Context) Warn(code int, text string) {
+	c.res.Header().Add("Warning", strconv.Itoa(code)+` - "`+quoteEscaper.Replace(text)+`"`)
+	c.AddWarning(errors.New(text))
+}
+
+// quoteEscaper escapes the content of an HTTP quoted-string.
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// Deprecation describes the deprecation of a route.
+type Deprecation struct {
+	// Since is when the route was deprecated, sent as the Deprecation
+	// header (RFC 9745) if set.
+	Since time.Time `json:"since,omitzero"`
+
+	// Sunset is when the route will stop responding, sent as the Sunset
+	// header (RFC 8594) if set.
+	Sunset time.Time `json:"sunset,omitzero"`
+
+	// Link points to documentation about the deprecation, e.g. a migration
+	// guide, sent as a Link header with rel="deprecation".
+	Link string `json:"link,omitempty"`
+
+	// Message is sent as a 299 Warning header.
+	// Default: "Deprecated API".
+	Message string `json:"message,omitempty"`
+}
+
+// Deprecated marks the route as deprecated. Every response then carries a
+// 299 Warning with the deprecation message and, when set, the Deprecation,
+// Sunset and Link headers, so clients get machine-readable notice.
+func (r *Route) Deprecated(d Deprecation) *Route {
+	if d.Message == "" {
+		d.Message = "Deprecated API"
+	}
+
+	r.app.mutex.Lock()
+	defer r.app.mutex.Unlock()
+	r.deprecation = &d
+	return r
+}
+
+// announce adds the deprecation notice to the response of c.
+func (d *Deprecation) announce(c *Context) {
+	h := c.res.Header()
+	if !d.Since.IsZero() {
+		h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+	}
+	if !d.Sunset.IsZero() {
+		h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		h.Add("Link", "<"+d.Link+`>; rel="deprecation"`)
+	}
+	c.Warn(299, d.Message)
+}
@@ -0,0 +1,367 @@
+// Package webhookout delivers signed webhooks to the endpoints registered
+// for an event, retrying failed deliveries with exponential backoff and
+// handing the ones it gives up on to a dead-letter callback.
+package webhookout
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/obadmatar/mux"
+)
+
+// Dispatcher errors.
+var (
+	ErrClosed    = errors.New("webhookout: dispatcher closed")
+	ErrQueueFull = errors.New("webhookout: queue full")
+)
+
+// AllEvents registers an endpoint for every event.
+const AllEvents = "*"
+
+// Endpoint is a webhook receiver.
+type Endpoint struct {
+	// URL receives the webhooks with POST requests.
+	URL string
+
+	// Secret signs the payloads sent to the endpoint. Without a secret,
+	// the signature header is omitted.
+	Secret []byte
+
+	// Header holds additional request headers, e.g. an API key.
+	Header http.Header
+}
+
+// Delivery is a webhook sent to an endpoint.
+type Delivery struct {
+	// ID identifies the webhook, and is the same across retries so
+	// receivers can drop duplicates.
+	ID string
+
+	// Event is the event type.
+	Event string
+
+	// Endpoint is the receiver of the webhook.
+	Endpoint Endpoint
+
+	// Payload is the JSON body.
+	Payload []byte
+
+	// Attempts is the number of delivery attempts made.
+	Attempts int
+
+	// Err is the error of the last attempt.
+	Err error
+}
+
+// Config defines the config for a Dispatcher.
+type Config struct {
+	// Client sends the webhooks.
+	//
+	// Default: an http.Client with a 10s timeout
+	Client *http.Client
+
+	// MaxAttempts is the number of attempts made before a delivery is
+	// given up on.
+	//
+	// Default: 5
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first retry. Each further retry
+	// doubles it.
+	//
+	// Default: 1s
+	BaseDelay time.Duration
+
+	// MaxDelay caps the wait between retries.
+	//
+	// Default: 5m
+	MaxDelay time.Duration
+
+	// Workers is the number of deliveries sent concurrently.
+	//
+	// Default: 4
+	Workers int
+
+	// QueueSize is the number of deliveries waiting for a worker before
+	// Publish fails with ErrQueueFull.
+	//
+	// Default: 1024
+	QueueSize int
+
+	// SignatureHeader carries the payload signature,
+	// "t=<unix time>,v1=<hex HMAC-SHA256 of the time, a dot and the body>".
+	//
+	// Default: "Webhook-Signature"
+	SignatureHeader string
+
+	// DeadLetter receives the deliveries given up on: after MaxAttempts,
+	// on a response that retrying cannot fix, or when the dispatcher shut
+	// down before delivering them.
+	//
+	// Default: nil
+	DeadLetter func(d Delivery)
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	MaxAttempts:     5,
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Minute,
+	Workers:         4,
+	QueueSize:       1024,
+	SignatureHeader: "Webhook-Signature",
+}
+
+// configDefault returns config with unset fields set to their defaults.
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		config = []Config{ConfigDefault}
+	}
+	cfg := config[0]
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = ConfigDefault.MaxAttempts
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = ConfigDefault.BaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = ConfigDefault.MaxDelay
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = ConfigDefault.Workers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = ConfigDefault.QueueSize
+	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = ConfigDefault.SignatureHeader
+	}
+	return cfg
+}
+
+// Dispatcher queues webhooks and delivers them from a pool of workers.
+type Dispatcher struct {
+	config Config
+
+	// mutex protects endpoints, closed and sends on queue.
+	mutex     sync.RWMutex
+	endpoints map[string][]Endpoint
+	closed    bool
+	queue     chan *Delivery
+
+	// ctx is cancelled when a shutdown runs out of time, aborting
+	// requests in flight and retry waits.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// workers tracks the running workers.
+	workers sync.WaitGroup
+}
+
+// New creates a Dispatcher and starts its workers.
+func New(config ...Config) *Dispatcher {
+	cfg := configDefault(config...)
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		config:    cfg,
+		endpoints: make(map[string][]Endpoint),
+		queue:     make(chan *Delivery, cfg.QueueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	d.workers.Add(cfg.Workers)
+	for range cfg.Workers {
+		go d.work()
+	}
+	return d
+}
+
+// Attach shuts the dispatcher down with app, so webhooks still queued are
+// delivered within the shutdown deadline.
+func (d *Dispatcher) Attach(app *mux.App) {
+	app.OnShutdown(d.Shutdown)
+}
+
+// Register adds endpoint as a receiver of event, or of every event for
+// AllEvents.
+func (d *Dispatcher) Register(event string, endpoint Endpoint) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.endpoints[event] = append(d.endpoints[event], endpoint)
+}
+
+// Publish encodes payload as JSON and queues a delivery of event to each
+// of its endpoints. It fails with ErrQueueFull when the queue cannot take
+// every delivery; the deliveries already queued are still sent.
+func (d *Dispatcher) Publish(event string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("webhookout: encode payload: %w", err)
+	}
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+	for _, endpoints := range [][]Endpoint{d.endpoints[event], d.endpoints[AllEvents]} {
+		for _, endpoint := range endpoints {
+			select {
+			case d.queue <- &Delivery{ID: id, Event: event, Endpoint: endpoint, Payload: body}:
+			default:
+				return ErrQueueFull
+			}
+		}
+	}
+	return nil
+}
+
+// Shutdown stops accepting webhooks and waits for the queued ones to be
+// delivered. If ctx is done first, deliveries in flight are aborted and
+// every undelivered one is handed to the dead-letter callback.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mutex.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// work delivers queued webhooks until the queue is closed.
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+	for delivery := range d.queue {
+		d.deliver(delivery)
+	}
+}
+
+// deliver sends delivery, retrying until it succeeds, fails permanently or
+// runs out of attempts.
+func (d *Dispatcher) deliver(delivery *Delivery) {
+	for {
+		delivery.Attempts++
+		retry, err := d.send(delivery)
+		delivery.Err = err
+		if err == nil {
+			return
+		}
+		if !retry || delivery.Attempts >= d.config.MaxAttempts || d.wait(delivery.Attempts) != nil {
+			if d.config.DeadLetter != nil {
+				d.config.DeadLetter(*delivery)
+			}
+			return
+		}
+	}
+}
+
+// wait sleeps before retry number attempt, or until the dispatcher is
+// aborted.
+func (d *Dispatcher) wait(attempt int) error {
+	delay := d.config.MaxDelay
+	if attempt <= 32 {
+		delay = min(d.config.BaseDelay<<(attempt-1), d.config.MaxDelay)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	}
+}
+
+// send makes one delivery attempt. It reports whether a failure may
+// succeed on retry: network errors, timeouts, throttling and 5xx
+// responses.
+func (d *Dispatcher) send(delivery *Delivery) (bool, error) {
+	if err := d.ctx.Err(); err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, delivery.Endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return false, err
+	}
+	for key, values := range delivery.Endpoint.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set(mux.HeaderContentType, mux.MIMEApplicationJSON)
+	req.Header.Set("Webhook-Id", delivery.ID)
+	req.Header.Set("Webhook-Event", delivery.Event)
+	if len(delivery.Endpoint.Secret) > 0 {
+		req.Header.Set(d.config.SignatureHeader, Sign(delivery.Endpoint.Secret, time.Now(), delivery.Payload))
+	}
+
+	res, err := d.config.Client.Do(req)
+	if err != nil {
+		return d.ctx.Err() == nil, err
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return true, nil
+	}
+	err = fmt.Errorf("webhookout: %s answered %s", delivery.Endpoint.URL, res.Status)
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusRequestTimeout || res.StatusCode == http.StatusTooManyRequests
+	return retry, err
+}
+
+// Sign returns the signature header value of body sent at t with secret,
+// as receivers should recompute it.
+func Sign(secret []byte, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newID returns a random delivery ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
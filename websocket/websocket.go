@@ -0,0 +1,526 @@
+// Package websocket upgrades mux routes to WebSocket connections (RFC 6455).
+//
+// The upgrade happens inside a regular mux.Handler, so WebSocket routes still
+// pass through the middleware chain, and handshake failures reach the
+// application's ErrorHandler as *mux.Error values.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/obadmatar/mux"
+)
+
+// Message types, as defined by RFC 6455 section 11.8.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// Close status codes, as defined by RFC 6455 section 7.4.1.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+)
+
+// acceptGUID is appended to the client key to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrReadLimit is returned by ReadMessage when a message exceeds Config.ReadLimit.
+var ErrReadLimit = errors.New("websocket: message exceeds read limit")
+
+// ErrClosed is returned when using a connection after Close.
+var ErrClosed = errors.New("websocket: connection closed")
+
+// CloseError is returned by ReadMessage when the peer closes the connection.
+type CloseError struct {
+	// Code is the close status code sent by the peer.
+	Code int
+
+	// Text is the close reason sent by the peer.
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: close %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: close %d: %s", e.Code, e.Text)
+}
+
+// Config defines the config for WebSocket handlers.
+type Config struct {
+	// CheckOrigin reports whether the Origin of the handshake request is
+	// allowed. Rejected handshakes get a 403 *mux.Error.
+	//
+	// Default: allows requests without Origin and same-host origins
+	CheckOrigin func(r *http.Request) bool
+
+	// Subprotocols lists the supported subprotocols in order of preference.
+	// The first one also offered by the client is selected.
+	//
+	// Default: nil
+	Subprotocols []string
+
+	// ReadLimit is the maximum size in bytes of a received message.
+	//
+	// Default: 1MB
+	ReadLimit int64
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	CheckOrigin: sameOrigin,
+	ReadLimit:   1 << 20,
+}
+
+// configDefault returns the config with defaults applied.
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.CheckOrigin == nil {
+		cfg.CheckOrigin = ConfigDefault.CheckOrigin
+	}
+	if cfg.ReadLimit <= 0 {
+		cfg.ReadLimit = ConfigDefault.ReadLimit
+	}
+	return cfg
+}
+
+// New returns a Handler that upgrades the request to a WebSocket connection
+// and runs handler with it. The connection is closed when handler returns,
+// with CloseInternalError if it returned an error, which is then passed on
+// to the application's ErrorHandler.
+func New(handler func(*Conn) error, config ...Config) mux.Handler {
+	cfg := configDefault(config...)
+
+	return mux.HandlerFunc(func(c *mux.Context) error {
+		conn, err := upgrade(c, cfg)
+		if err != nil {
+			return err
+		}
+
+		if err := handler(conn); err != nil {
+			conn.CloseWithStatus(CloseInternalError, "")
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+// upgrade performs the opening handshake and hijacks the connection.
+func upgrade(c *mux.Context, cfg Config) (*Conn, error) {
+	r := c.Request()
+
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, mux.NewError(http.StatusBadRequest, "not a WebSocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.Response().Header().Set("Sec-WebSocket-Version", "13")
+		return nil, mux.NewError(http.StatusUpgradeRequired, "unsupported WebSocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, mux.NewError(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	if !cfg.CheckOrigin(r) {
+		return nil, mux.NewError(http.StatusForbidden, "origin not allowed")
+	}
+	subprotocol := selectSubprotocol(r, cfg.Subprotocols)
+
+	netConn, brw, err := http.NewResponseController(c.Response()).Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// The server may have set deadlines on the connection, they no longer apply.
+	netConn.SetDeadline(time.Time{})
+
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		brw.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	brw.WriteString("\r\n")
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	conn := &Conn{
+		conn:        netConn,
+		br:          brw.Reader,
+		request:     r,
+		subprotocol: subprotocol,
+		readLimit:   cfg.ReadLimit,
+	}
+	conn.ctx, conn.untrack = c.TrackStream(func() { netConn.Close() })
+	go conn.closeOnShutdown()
+	return conn, nil
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains reports whether the comma separated header name contains
+// token, ignoring case.
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for part := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectSubprotocol returns the first supported subprotocol offered by the client.
+func selectSubprotocol(r *http.Request, supported []string) string {
+	var offered []string
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for part := range strings.SplitSeq(value, ",") {
+			offered = append(offered, strings.TrimSpace(part))
+		}
+	}
+	for _, protocol := range supported {
+		if slices.Contains(offered, protocol) {
+			return protocol
+		}
+	}
+	return ""
+}
+
+// sameOrigin is the default Config.CheckOrigin.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// Conn is an upgraded WebSocket connection. Reads must come from a single
+// goroutine; writes are safe for concurrent use.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	request     *http.Request
+	subprotocol string
+	readLimit   int64
+
+	// writeMutex serializes frame writes.
+	writeMutex sync.Mutex
+
+	// closeSent is set once a close frame was written.
+	closeSent bool
+
+	// ctx is canceled when the connection ends or the application shuts down.
+	ctx context.Context
+
+	// untrack ends the stream registration for graceful shutdown.
+	untrack func()
+
+	// closeOnce guards closing the network connection.
+	closeOnce sync.Once
+}
+
+// Context returns the context of the connection. It is canceled when the
+// connection ends, and with mux.ErrServerShutdown when the application
+// starts shutting down; a CloseGoingAway frame is then sent to the peer.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// closeOnShutdown sends CloseGoingAway when the application shuts down.
+// ReadMessage then returns once the peer acknowledges the close.
+func (c *Conn) closeOnShutdown() {
+	<-c.ctx.Done()
+	if context.Cause(c.ctx) == mux.ErrServerShutdown {
+		c.writeMutex.Lock()
+		c.sendClose(CloseGoingAway, "server shutting down")
+		c.writeMutex.Unlock()
+	}
+}
+
+// Request returns the handshake request.
+func (c *Conn) Request() *http.Request {
+	return c.request
+}
+
+// Subprotocol returns the negotiated subprotocol, if any.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets the deadline for reading the next message.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for writing messages.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// ReadMessage reads the next text or binary message. Pings are answered
+// and pongs are discarded while waiting. When the peer closes the
+// connection, the close is echoed and a *CloseError is returned.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			messageType = opcode
+		case 0:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if int64(len(data)+len(payload)) > c.readLimit {
+			c.CloseWithStatus(CloseMessageTooBig, "")
+			return 0, nil, ErrReadLimit
+		}
+		data = append(data, payload...)
+
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8")
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+// readFrame reads a single frame and unmasks its payload.
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0f)
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "client frame not masked")
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	if opcode >= CloseMessage && (!fin || length > 125) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if length < 0 || length > c.readLimit {
+		c.CloseWithStatus(CloseMessageTooBig, "")
+		return false, 0, nil, ErrReadLimit
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// handleClose echoes the close frame of the peer and returns it as a
+// *CloseError. A close frame without payload is reported with
+// CloseNoStatusReceived and echoed without payload. Malformed payloads and
+// codes a peer must not send fail the connection.
+func (c *Conn) handleClose(payload []byte) error {
+	e := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "close frame payload too short")
+	case len(payload) >= 2:
+		e.Code = int(binary.BigEndian.Uint16(payload))
+		e.Text = string(payload[2:])
+		if !validCloseCode(e.Code) {
+			return c.fail(CloseProtocolError, fmt.Sprintf("invalid close code %d", e.Code))
+		}
+		if !utf8.Valid(payload[2:]) {
+			return c.fail(CloseInvalidPayload, "invalid UTF-8 in close reason")
+		}
+	}
+	c.CloseWithStatus(e.Code, "")
+	return e
+}
+
+// validCloseCode reports whether a peer may send code in a close frame:
+// the defined codes except those reserved for local use (1004, 1005,
+// 1006 and 1015), and the codes for libraries and applications.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+// fail closes the connection after a protocol violation by the peer.
+func (c *Conn) fail(code int, reason string) error {
+	c.CloseWithStatus(code, reason)
+	return fmt.Errorf("websocket: %s", reason)
+}
+
+// WriteMessage writes data as a single frame of the given message type.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// WriteText writes s as a text message.
+func (c *Conn) WriteText(s string) error {
+	return c.WriteMessage(TextMessage, []byte(s))
+}
+
+// writeFrame writes an unmasked final frame. The caller must hold writeMutex.
+func (c *Conn) writeFrame(opcode int, data []byte) error {
+	header := make([]byte, 2, 10+len(data))
+	header[0] = 0x80 | byte(opcode)
+	switch n := len(data); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	_, err := c.conn.Write(append(header, data...))
+	return err
+}
+
+// Close sends a normal closure and closes the connection.
+func (c *Conn) Close() error {
+	return c.CloseWithStatus(CloseNormalClosure, "")
+}
+
+// CloseWithStatus sends a close frame with code and reason, unless one was
+// already sent, and closes the connection.
+func (c *Conn) CloseWithStatus(code int, reason string) error {
+	c.writeMutex.Lock()
+	c.sendClose(code, reason)
+	c.writeMutex.Unlock()
+
+	var err error
+	c.closeOnce.Do(func() {
+		err = c.conn.Close()
+		c.untrack()
+	})
+	return err
+}
+
+// sendClose writes a close frame unless one was already sent.
+// The caller must hold writeMutex.
+func (c *Conn) sendClose(code int, reason string) {
+	if c.closeSent {
+		return
+	}
+	c.closeSent = true
+
+	// CloseNoStatusReceived must not be sent; it is echoed as an empty
+	// close frame.
+	var payload []byte
+	if code != CloseNoStatusReceived {
+		payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+		payload = append(payload, reason...)
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	c.writeFrame(CloseMessage, payload)
+	c.conn.SetWriteDeadline(time.Time{})
+}
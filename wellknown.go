@@ -0,0 +1,111 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WellKnownConfig describes the /.well-known/ entries served by App.WellKnown.
+// Only the entries that are set are registered.
+type WellKnownConfig struct {
+	// SecurityTxt is served as /.well-known/security.txt (RFC 9116).
+	SecurityTxt *SecurityTxt
+
+	// ChangePasswordURL is the redirect target of /.well-known/change-password.
+	ChangePasswordURL string
+
+	// OpenIDConfiguration is served as is as /.well-known/openid-configuration,
+	// typically the discovery document of the identity provider.
+	OpenIDConfiguration json.RawMessage
+
+	// AssetLinks is served as /.well-known/assetlinks.json for Android app links.
+	AssetLinks []AssetLink
+}
+
+// SecurityTxt holds the fields of a security.txt file.
+type SecurityTxt struct {
+	// Contact lists URIs for reporting vulnerabilities. Required.
+	Contact []string
+
+	// Expires is the date after which the file is considered stale. Required.
+	Expires time.Time
+
+	Encryption         []string
+	Acknowledgments    []string
+	Policy             []string
+	Hiring             []string
+	Canonical          []string
+	PreferredLanguages []string
+}
+
+// String renders s in the security.txt format.
+func (s *SecurityTxt) String() string {
+	var sb strings.Builder
+	field := func(name string, values []string) {
+		for _, v := range values {
+			sb.WriteString(name + ": " + v + "\n")
+		}
+	}
+
+	field("Contact", s.Contact)
+	sb.WriteString("Expires: " + s.Expires.UTC().Format(time.RFC3339) + "\n")
+	field("Encryption", s.Encryption)
+	field("Acknowledgments", s.Acknowledgments)
+	field("Policy", s.Policy)
+	field("Hiring", s.Hiring)
+	field("Canonical", s.Canonical)
+	if len(s.PreferredLanguages) > 0 {
+		sb.WriteString("Preferred-Languages: " + strings.Join(s.PreferredLanguages, ", ") + "\n")
+	}
+	return sb.String()
+}
+
+// AssetLink is a statement of a Digital Asset Links file.
+type AssetLink struct {
+	Relation []string        `json:"relation"`
+	Target   AssetLinkTarget `json:"target"`
+}
+
+// AssetLinkTarget is the target of an AssetLink statement.
+type AssetLinkTarget struct {
+	Namespace              string   `json:"namespace"`
+	PackageName            string   `json:"package_name,omitempty"`
+	SHA256CertFingerprints []string `json:"sha256_cert_fingerprints,omitempty"`
+	Site                   string   `json:"site,omitempty"`
+}
+
+// WellKnown registers GET routes for the /.well-known/ entries set in cfg.
+func (app *App) WellKnown(cfg WellKnownConfig) {
+	if cfg.SecurityTxt != nil {
+		body := cfg.SecurityTxt.String()
+		app.Get("/.well-known/security.txt", HandlerFunc(func(c *Context) error {
+			c.res.Header().Set(HeaderContentType, "text/plain; charset=utf-8")
+			_, err := c.res.Write([]byte(body))
+			return err
+		}))
+	}
+
+	if cfg.ChangePasswordURL != "" {
+		app.Get("/.well-known/change-password", HandlerFunc(func(c *Context) error {
+			http.Redirect(c.res, c.req, cfg.ChangePasswordURL, http.StatusFound)
+			return nil
+		}))
+	}
+
+	if len(cfg.OpenIDConfiguration) > 0 {
+		app.Get("/.well-known/openid-configuration", HandlerFunc(func(c *Context) error {
+			c.res.Header().Set(HeaderContentType, MIMEApplicationJSON)
+			_, err := c.res.Write(cfg.OpenIDConfiguration)
+			return err
+		}))
+	}
+
+	if cfg.AssetLinks != nil {
+		// The protocol document is never wrapped in the response envelope.
+		app.Get("/.well-known/assetlinks.json", HandlerFunc(func(c *Context) error {
+			return c.writeJSON(http.StatusOK, cfg.AssetLinks)
+		}))
+	}
+}
@@ -0,0 +1,101 @@
+package mux
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseWriter wraps the http.ResponseWriter of a request to record the
+// status code and the number of body bytes written.
+// It is embedded in Context so wrapping does not allocate.
+type responseWriter struct {
+	http.ResponseWriter
+
+	// status is the status code sent, or 0 if the header was not written yet.
+	status int
+
+	// size is the number of body bytes written.
+	size int64
+}
+
+// reset prepares the writer for a new request.
+func (w *responseWriter) reset(res http.ResponseWriter) {
+	w.ResponseWriter = res
+	w.status = 0
+	w.size = 0
+}
+
+// WriteHeader records the first final status code and forwards it.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the implicit 200 status and the number of bytes written.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// ReadFrom forwards to the underlying writer so sendfile optimizations keep working.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, r)
+	}
+	w.size += n
+	return n, err
+}
+
+// Flush implements http.Flusher when the underlying writer supports it.
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the underlying writer supports it.
+// A hijacked response counts as written with 101 Switching Protocols, so
+// error handlers do not write to it.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status code sent, defaulting to 200 when nothing was written.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Written reports whether the response header has been sent.
+func (w *responseWriter) Written() bool {
+	return w.status != 0
+}